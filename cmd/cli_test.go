@@ -67,6 +67,14 @@ func TestCLIContract(t *testing.T) {
 			expectStderr:   nil,
 			description:    "trace help should show command description and flags",
 		},
+		{
+			name:           "help_doctor",
+			args:           []string{"doctor", "--help"},
+			expectExitCode: 0,
+			expectStdout:   []string{"Check what snitch can see on this host", "Usage:", "--output"},
+			expectStderr:   nil,
+			description:    "doctor help should show command description and flags",
+		},
 		{
 			name:           "version",
 			args:           []string{"version"},
@@ -166,6 +174,27 @@ func TestFlagInteractions(t *testing.T) {
 			expectError: false,
 			description: "CSV output flag should produce CSV format",
 		},
+		{
+			name:        "markdown_output",
+			args:        []string{"ls", "-o", "markdown", "-f", "pid,process"},
+			expectOut:   []string{"| PID | PROCESS |", "| 1 | tcp-server |"},
+			expectError: false,
+			description: "Markdown output should render a pipe table",
+		},
+		{
+			name:        "template_output",
+			args:        []string{"ls", "-o", "template={{.Process}}/{{.PID}}"},
+			expectOut:   []string{"tcp-server/1", "udp-server/2"},
+			expectError: false,
+			description: "Template output should render each connection",
+		},
+		{
+			name:        "group_by_flag",
+			args:        []string{"ls", "--group-by", "proto", "-o", "csv"},
+			expectOut:   []string{"PROTO,COUNT,RX_BYTES,TX_BYTES,AVG_RTT_MS", "tcp,1,", "udp,1,"},
+			expectError: false,
+			description: "Group by flag should aggregate connections",
+		},
 		{
 			name:        "no_headers_flag",
 			args:        []string{"ls", "--no-headers"},
@@ -0,0 +1,122 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+
+	"github.com/karol-broda/snitch/internal/collector"
+	"github.com/karol-broda/snitch/internal/config"
+	"github.com/karol-broda/snitch/internal/theme"
+)
+
+var doctorOutputFormat string
+
+var doctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "Check what snitch can see on this host",
+	Long: `Check what snitch can see on this host, and why.
+
+Reports privileges, ptrace and /proc restrictions, the namespace, sock_diag
+and container attribution sources, and the resolved config file and theme.
+Each check passes, warns or fails with a hint on how to fix it. The command
+exits with status 1 when any check fails.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		checks := runDoctorChecks()
+
+		switch doctorOutputFormat {
+		case "json":
+			printDoctorJSON(checks)
+		case "table":
+			printDoctorTable(checks)
+		default:
+			fmt.Fprintf(os.Stderr, "Invalid output format: %s. Valid formats are: table, json\n", doctorOutputFormat)
+			os.Exit(1)
+		}
+
+		for _, check := range checks {
+			if check.Status == collector.CheckFail {
+				os.Exit(1)
+			}
+		}
+	},
+}
+
+// runDoctorChecks runs the collector's host checks followed by the checks
+// of the configuration snitch resolved
+func runDoctorChecks() []collector.Check {
+	checks := collector.RunChecks()
+	return append(checks, checkConfig(), checkTheme())
+}
+
+func checkConfig() collector.Check {
+	check := collector.Check{Name: "config"}
+
+	if _, err := config.Load(); err != nil {
+		check.Status = collector.CheckFail
+		check.Detail = err.Error()
+		check.Hint = "fix the config file or point SNITCH_CONFIG at another one"
+		return check
+	}
+
+	check.Status = collector.CheckPass
+	if file := config.File(); file != "" {
+		check.Detail = file
+	} else {
+		check.Detail = "no config file, using defaults"
+	}
+	return check
+}
+
+func checkTheme() collector.Check {
+	check := collector.Check{Name: "theme"}
+
+	name := config.Get().Defaults.Theme
+	resolved, known := theme.ResolveName(name)
+	check.Detail = fmt.Sprintf("%s -> %s", name, resolved)
+	if !known {
+		check.Status = collector.CheckWarn
+		check.Hint = fmt.Sprintf("unknown theme %q; use auto, dark, light or mono", name)
+		return check
+	}
+	check.Status = collector.CheckPass
+	return check
+}
+
+func printDoctorJSON(checks []collector.Check) {
+	out, err := json.MarshalIndent(checks, "", "  ")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error marshaling to JSON: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Println(string(out))
+}
+
+func printDoctorTable(checks []collector.Check) {
+	labels := map[collector.CheckStatus]*color.Color{
+		collector.CheckPass: color.New(color.FgGreen),
+		collector.CheckWarn: color.New(color.FgYellow),
+		collector.CheckFail: color.New(color.FgRed, color.Bold),
+	}
+	bold := color.New(color.Bold)
+	faint := color.New(color.Faint)
+
+	for _, check := range checks {
+		fmt.Print("  ")
+		labels[check.Status].Printf("%-4s", check.Status)
+		fmt.Print("  ")
+		bold.Printf("%-14s", check.Name)
+		fmt.Println(check.Detail)
+		if check.Hint != "" {
+			faint.Printf("%22s%s\n", "", check.Hint)
+		}
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(doctorCmd)
+	doctorCmd.Flags().StringVarP(&doctorOutputFormat, "output", "o", "table", "Output format (table, json)")
+}
@@ -9,6 +9,7 @@ var jsonCmd = &cobra.Command{
 	Short: "One-shot json output of connections",
 	Long:  `One-shot json output of connections. This is an alias for "ls -o json".`,
 	Run: func(cmd *cobra.Command, args []string) {
+		applySavedQueryOptions(cmd, args)
 		runListCommand("json", args)
 	},
 }
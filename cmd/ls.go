@@ -1,25 +1,17 @@
 package cmd
 
 import (
-	"encoding/csv"
-	"encoding/json"
 	"fmt"
-	"io"
 	"log"
 	"os"
-	"os/exec"
 	"github.com/karol-broda/snitch/internal/collector"
-	"github.com/karol-broda/snitch/internal/color"
 	"github.com/karol-broda/snitch/internal/config"
 	"github.com/karol-broda/snitch/internal/resolver"
+	"slices"
 	"strconv"
 	"strings"
-	"text/tabwriter"
 
-	"github.com/charmbracelet/lipgloss"
 	"github.com/spf13/cobra"
-	"github.com/tidwall/pretty"
-	"golang.org/x/term"
 )
 
 // ls-specific flags
@@ -29,9 +21,11 @@ var (
 	showTimestamp bool
 	sortBy        string
 	fields        string
+	fieldsSet     bool
 	colorMode     string
 	numeric       bool
 	plainOutput   bool
+	groupBy       string
 )
 
 var lsCmd = &cobra.Command{
@@ -39,23 +33,67 @@ var lsCmd = &cobra.Command{
 	Short: "One-shot listing of connections",
 	Long: `One-shot listing of connections.
 
-Filters are specified in key=value format. For example:
+Filters are key=value pairs, combined with and, or, not and parentheses.
+For example:
   snitch ls proto=tcp state=established
+  snitch ls 'proto=tcp and (lport in 80,443 or raddr ~ "^10\.") and not proc=chrome'
+
+Saved queries from the config file are run as @name, e.g. snitch ls @db.
 
 Available filters:
-  proto, state, pid, proc, lport, rport, user, laddr, raddr, contains, if, mark, namespace, inode, since
+  proto, state, pid, proc, cmdline, lport, rport, user, laddr, raddr, contains, if, mark, namespace, container, inode, since
 `,
 	Run: func(cmd *cobra.Command, args []string) {
+		fieldsSet = cmd.Flags().Changed("fields")
+		applySavedQueryOptions(cmd, args)
 		runListCommand(outputFormat, args)
 	},
 }
 
+// applySavedQueryOptions takes the sort and fields of the saved queries in
+// args, unless -s or -f was given
+func applySavedQueryOptions(cmd *cobra.Command, args []string) {
+	for _, q := range savedQueries(args) {
+		if q.Sort != "" && !cmd.Flags().Changed("sort") {
+			sortBy = q.Sort
+		}
+		if len(q.Fields) > 0 && !cmd.Flags().Changed("fields") {
+			fields = strings.Join(q.Fields, ",")
+			fieldsSet = true
+		}
+	}
+}
+
 func runListCommand(outputFormat string, args []string) {
+	// -p keeps the columns of -o wide
+	wide := outputFormat == "wide"
+	if plainOutput && (outputFormat == "table" || outputFormat == "wide") {
+		outputFormat = "plain"
+	}
+	renderer, err := rendererFor(outputFormat, nil)
+	if err != nil {
+		log.Fatal(err)
+	}
+
 	rt, err := NewRuntime(args, colorMode, numeric)
 	if err != nil {
 		log.Fatal(err)
 	}
 
+	if groupBy != "" {
+		groupFields, err := collector.ParseGroupFields(groupBy)
+		if err != nil {
+			log.Fatalf("Error parsing --group-by: %v", err)
+		}
+		groups, err := collector.GroupConnections(rt.Connections, groupFields)
+		if err != nil {
+			log.Fatal(err)
+		}
+		renderOutput(renderer, groupsOutput(groups, groupFields))
+		reportDiagnostics()
+		return
+	}
+
 	// apply sorting
 	if sortBy != "" {
 		rt.SortConnections(collector.ParseSortOptions(sortBy))
@@ -66,45 +104,103 @@ func runListCommand(outputFormat string, args []string) {
 		})
 	}
 
-	selectedFields := []string{}
-	if fields != "" {
-		selectedFields = strings.Split(fields, ",")
+	var selectedFields []string
+	switch {
+	case rt.Filters.Proto == "unix" && !fieldsSet:
+		// unix sockets have no ports, show their type and peer instead
+		selectedFields = []string{"process", "pid", "type", "state", "laddr", "raddr", "peer"}
+	case wide && !fieldsSet:
+		selectedFields = wideFields(defaultListFields())
+	default:
+		selectedFields = selectFields(defaultListFields())
+	}
+
+	rows := connectionRows(rt.Connections)
+	if wide {
+		rows = wideRows(rt.Connections)
 	}
 
-	renderList(rt.Connections, outputFormat, selectedFields)
+	renderOutput(renderer, Output{
+		Items:   toItems(rt.Connections),
+		Value:   rt.Connections,
+		Fields:  selectedFields,
+		Rows:    rows,
+		Headers: !noHeaders,
+		Summary: fmt.Sprintf("%d connections", len(rt.Connections)),
+	})
+	reportDiagnostics()
 }
 
-func renderList(connections []collector.Connection, format string, selectedFields []string) {
-	switch format {
-	case "json":
-		printJSON(connections)
-	case "csv":
-		printCSV(connections, !noHeaders, showTimestamp, selectedFields)
-	case "table", "wide":
-		if plainOutput {
-			printPlainTable(connections, !noHeaders, showTimestamp, selectedFields)
-		} else {
-			printStyledTable(connections, !noHeaders, selectedFields)
-		}
-	default:
-		log.Fatalf("Invalid output format: %s. Valid formats are: table, wide, json, csv", format)
+// renderOutput writes out to stdout, exiting when it cannot
+func renderOutput(r Renderer, out Output) {
+	if err := r.Render(os.Stdout, out); err != nil {
+		log.Fatalf("Error rendering output: %v", err)
+	}
+}
+
+// defaultListFields are the columns of connection listings without
+// --fields: the fields of the config file, after the timestamp with --ts
+func defaultListFields() []string {
+	defaults := config.Get().Defaults.Fields
+	if showTimestamp {
+		defaults = append([]string{"ts"}, defaults...)
 	}
+	return defaults
 }
 
+// groupColumns are the aggregates shown after the grouped fields
+var groupColumns = []string{"count", "rx_bytes", "tx_bytes", "avg_rtt_ms"}
+
+func groupsOutput(groups []collector.Group, groupFields []string) Output {
+	rows := make([]map[string]string, 0, len(groups))
+	for _, g := range groups {
+		rows = append(rows, getGroupFieldMap(g, groupFields))
+	}
+
+	return Output{
+		Items:   toItems(groups),
+		Value:   groups,
+		Fields:  append(append([]string{}, groupFields...), groupColumns...),
+		Rows:    rows,
+		Headers: !noHeaders,
+		Summary: fmt.Sprintf("%d groups", len(groups)),
+	}
+}
+
+func getGroupFieldMap(g collector.Group, groupFields []string) map[string]string {
+	fm := map[string]string{
+		"count":      strconv.Itoa(g.Count),
+		"rx_bytes":   strconv.FormatInt(g.RxBytes, 10),
+		"tx_bytes":   strconv.FormatInt(g.TxBytes, 10),
+		"avg_rtt_ms": strconv.FormatFloat(g.AvgRttMs, 'f', 1, 64),
+	}
+	for _, f := range groupFields {
+		value := g.Key[f]
+		if !numeric && (f == "laddr" || f == "raddr") && value != "*" && value != "" {
+			value = resolver.ResolveAddr(value)
+		}
+		fm[f] = value
+	}
+	return fm
+}
 
 func getFieldMap(c collector.Connection) map[string]string {
 	laddr := c.Laddr
 	raddr := c.Raddr
 	lport := strconv.Itoa(c.Lport)
 	rport := strconv.Itoa(c.Rport)
+	lhost := ""
+	rhost := ""
 	
 	// Apply name resolution if not in numeric mode
 	if !numeric {
 		if resolvedLaddr := resolver.ResolveAddr(c.Laddr); resolvedLaddr != c.Laddr {
 			laddr = resolvedLaddr
+			lhost = resolvedLaddr
 		}
 		if resolvedRaddr := resolver.ResolveAddr(c.Raddr); resolvedRaddr != c.Raddr && c.Raddr != "*" && c.Raddr != "" {
 			raddr = resolvedRaddr
+			rhost = resolvedRaddr
 		}
 		if resolvedLport := resolver.ResolvePort(c.Lport, c.Proto); resolvedLport != strconv.Itoa(c.Lport) {
 			lport = resolvedLport
@@ -114,273 +210,126 @@ func getFieldMap(c collector.Connection) map[string]string {
 		}
 	}
 	
+	namespace := c.Namespace
+	if namespace == "" && c.NamespaceInode != 0 {
+		namespace = strconv.FormatInt(c.NamespaceInode, 10)
+	}
+
+	container := c.ContainerID
+	if len(container) > 12 {
+		container = container[:12]
+	}
+
+	pod := c.PodName
+	if pod == "" {
+		pod = c.PodUID
+	}
+
+	peer := ""
+	if c.PeerProcess != "" {
+		peer = fmt.Sprintf("%s[%d]", c.PeerProcess, c.PeerPID)
+	} else if c.PeerInode != 0 {
+		peer = strconv.FormatInt(c.PeerInode, 10)
+	}
+
+	// sockets inherited across fork are held by several processes
+	process := c.Process
+	if len(c.Owners) > 1 && process != "" {
+		process = fmt.Sprintf("%s (+%d)", process, len(c.Owners)-1)
+	}
+
+	start := ""
+	if !c.StartTime.IsZero() {
+		start = c.StartTime.Format("2006-01-02T15:04:05Z07:00")
+	}
+
 	return map[string]string{
 		"pid":       strconv.Itoa(c.PID),
-		"process":   c.Process,
+		"process":   process,
+		"cmdline":   c.Cmdline,
+		"exe":       c.Exe,
+		"cwd":       c.Cwd,
+		"ppid":      strconv.Itoa(c.PPID),
+		"tgid":      strconv.Itoa(c.TGID),
+		"start":     start,
 		"user":      c.User,
 		"uid":       strconv.Itoa(c.UID),
 		"proto":     c.Proto,
+		"type":      c.SockType,
 		"ipversion": c.IPVersion,
 		"state":     c.State,
 		"laddr":     laddr,
+		"lhost":     lhost,
 		"lport":     lport,
 		"raddr":     raddr,
+		"rhost":     rhost,
 		"rport":     rport,
 		"if":        c.Interface,
 		"rx_bytes":  strconv.FormatInt(c.RxBytes, 10),
 		"tx_bytes":  strconv.FormatInt(c.TxBytes, 10),
+		"rx/s":      strconv.FormatFloat(c.RxRate, 'f', 0, 64),
+		"tx/s":      strconv.FormatFloat(c.TxRate, 'f', 0, 64),
 		"rtt_ms":    strconv.FormatFloat(c.RttMs, 'f', 1, 64),
 		"mark":      c.Mark,
-		"namespace": c.Namespace,
+		"namespace": namespace,
 		"inode":     strconv.FormatInt(c.Inode, 10),
+		"container": container,
+		"runtime":   c.ContainerRuntime,
+		"pod":       pod,
+		"peer":      peer,
+		"peer_pid":  strconv.Itoa(c.PeerPID),
+		"send_q":    strconv.Itoa(c.SendQ),
+		"recv_q":    strconv.Itoa(c.RecvQ),
+		"retrans":   strconv.Itoa(c.Retransmits),
+		"cwnd":      strconv.Itoa(c.Cwnd),
+		"timer":     c.Timer,
 		"ts":        c.TS.Format("2006-01-02T15:04:05.000Z07:00"),
 	}
 }
 
-func printJSON(conns []collector.Connection) {
-	jsonOutput, err := json.MarshalIndent(conns, "", "  ")
-	if err != nil {
-		log.Fatalf("Error marshaling to JSON: %v", err)
-	}
-
-	if color.IsColorDisabled() {
-		fmt.Println(string(jsonOutput))
-	} else {
-		colored := pretty.Color(jsonOutput, nil)
-		fmt.Println(string(colored))
-	}
-}
-
-func printCSV(conns []collector.Connection, headers bool, timestamp bool, selectedFields []string) {
-	writer := csv.NewWriter(os.Stdout)
-	defer writer.Flush()
-
-	if len(selectedFields) == 0 {
-		selectedFields = []string{"pid", "process", "user", "uid", "proto", "state", "laddr", "lport", "raddr", "rport"}
-		if timestamp {
-			selectedFields = append([]string{"ts"}, selectedFields...)
-		}
-	}
-
-	if headers {
-		headerRow := []string{}
-		for _, field := range selectedFields {
-			headerRow = append(headerRow, strings.ToUpper(field))
-		}
-		_ = writer.Write(headerRow)
-	}
-
-	for _, conn := range conns {
-		fieldMap := getFieldMap(conn)
-		row := []string{}
-		for _, field := range selectedFields {
-			row = append(row, fieldMap[field])
-		}
-		_ = writer.Write(row)
-	}
-}
-
-func printPlainTable(conns []collector.Connection, headers bool, timestamp bool, selectedFields []string) {
-	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
-	defer w.Flush()
-
-	if len(selectedFields) == 0 {
-		selectedFields = []string{"pid", "process", "user", "proto", "state", "laddr", "lport", "raddr", "rport"}
-		if timestamp {
-			selectedFields = append([]string{"ts"}, selectedFields...)
-		}
-	}
-
-	if headers {
-		headerRow := []string{}
-		for _, field := range selectedFields {
-			headerRow = append(headerRow, strings.ToUpper(field))
-		}
-		fmt.Fprintln(w, strings.Join(headerRow, "\t"))
-	}
-
+// connectionRows maps every connection to its printable fields
+func connectionRows(conns []collector.Connection) []map[string]string {
+	rows := make([]map[string]string, 0, len(conns))
 	for _, conn := range conns {
-		fieldMap := getFieldMap(conn)
-		row := []string{}
-		for _, field := range selectedFields {
-			row = append(row, fieldMap[field])
-		}
-		fmt.Fprintln(w, strings.Join(row, "\t"))
+		rows = append(rows, getFieldMap(conn))
 	}
+	return rows
 }
 
-func printStyledTable(conns []collector.Connection, headers bool, selectedFields []string) {
-	if len(selectedFields) == 0 {
-		selectedFields = []string{"process", "pid", "proto", "state", "laddr", "lport", "raddr", "rport"}
-	}
-
-	// calculate column widths
-	widths := make(map[string]int)
-	for _, f := range selectedFields {
-		widths[f] = len(strings.ToUpper(f))
-	}
-
-	for _, conn := range conns {
-		fm := getFieldMap(conn)
-		for _, f := range selectedFields {
-			if len(fm[f]) > widths[f] {
-				widths[f] = len(fm[f])
-			}
-		}
-	}
-
-	// cap and pad widths
-	for f := range widths {
-		if widths[f] > 25 {
-			widths[f] = 25
-		}
-		widths[f] += 2 // padding
-	}
-
-	// build output
-	var output strings.Builder
-
-	// styles
-	borderStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("240"))
-	headerStyle := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("15"))
-	processStyle := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("15"))
-	faintStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("245"))
-
-	// build top border
-	output.WriteString("\n")
-	output.WriteString(borderStyle.Render("  ╭"))
-	for i, f := range selectedFields {
-		if i > 0 {
-			output.WriteString(borderStyle.Render("┬"))
-		}
-		output.WriteString(borderStyle.Render(strings.Repeat("─", widths[f])))
-	}
-	output.WriteString(borderStyle.Render("╮"))
-	output.WriteString("\n")
-
-	// header row
-	if headers {
-		output.WriteString(borderStyle.Render("  │"))
-		for i, f := range selectedFields {
-			if i > 0 {
-				output.WriteString(borderStyle.Render("│"))
-			}
-			cell := fmt.Sprintf(" %-*s", widths[f]-1, strings.ToUpper(f))
-			output.WriteString(headerStyle.Render(cell))
-		}
-		output.WriteString(borderStyle.Render("│"))
-		output.WriteString("\n")
-
-		// header separator
-		output.WriteString(borderStyle.Render("  ├"))
-		for i, f := range selectedFields {
-			if i > 0 {
-				output.WriteString(borderStyle.Render("┼"))
-			}
-			output.WriteString(borderStyle.Render(strings.Repeat("─", widths[f])))
-		}
-		output.WriteString(borderStyle.Render("┤"))
-		output.WriteString("\n")
-	}
-
-	// data rows
-	for _, conn := range conns {
-		fm := getFieldMap(conn)
-		output.WriteString(borderStyle.Render("  │"))
-		for i, f := range selectedFields {
-			if i > 0 {
-				output.WriteString(borderStyle.Render("│"))
-			}
-			val := fm[f]
-			maxW := widths[f] - 2
-			if len(val) > maxW {
-				val = val[:maxW-1] + "…"
-			}
-			cell := fmt.Sprintf(" %-*s ", maxW, val)
-
-			switch f {
-			case "proto":
-				c := lipgloss.Color("37") // cyan
-				if strings.Contains(fm["proto"], "udp") {
-					c = lipgloss.Color("135") // purple
-				}
-				output.WriteString(lipgloss.NewStyle().Foreground(c).Render(cell))
-			case "state":
-				c := lipgloss.Color("245") // gray
-				switch strings.ToUpper(fm["state"]) {
-				case "LISTEN":
-					c = lipgloss.Color("35") // green
-				case "ESTABLISHED":
-					c = lipgloss.Color("33") // blue
-				case "TIME_WAIT", "CLOSE_WAIT":
-					c = lipgloss.Color("178") // yellow
-				}
-				output.WriteString(lipgloss.NewStyle().Foreground(c).Render(cell))
-			case "process":
-				output.WriteString(processStyle.Render(cell))
-			default:
-				output.WriteString(cell)
-			}
+// wideColumns are the columns -o wide adds for debugging, after the
+// configured fields that do not already show them
+var wideColumns = []string{"user", "uid", "ipversion", "if", "inode", "namespace", "rtt_ms", "rx_bytes", "tx_bytes"}
+
+// wideFields extends fields with wideColumns and puts the hostname column
+// of an address next to it
+func wideFields(fields []string) []string {
+	wide := make([]string, 0, len(fields)+len(wideColumns)+2)
+	for _, f := range fields {
+		wide = append(wide, f)
+		switch f {
+		case "laddr":
+			wide = append(wide, "lhost")
+		case "raddr":
+			wide = append(wide, "rhost")
 		}
-		output.WriteString(borderStyle.Render("│"))
-		output.WriteString("\n")
 	}
-
-	// bottom border
-	output.WriteString(borderStyle.Render("  ╰"))
-	for i, f := range selectedFields {
-		if i > 0 {
-			output.WriteString(borderStyle.Render("┴"))
+	for _, f := range wideColumns {
+		if !slices.Contains(wide, f) {
+			wide = append(wide, f)
 		}
-		output.WriteString(borderStyle.Render(strings.Repeat("─", widths[f])))
 	}
-	output.WriteString(borderStyle.Render("╯"))
-	output.WriteString("\n")
-
-	// summary
-	output.WriteString(faintStyle.Render(fmt.Sprintf("  %d connections\n", len(conns))))
-	output.WriteString("\n")
-
-	// output with pager if needed
-	printWithPager(output.String())
+	return wide
 }
 
-func printWithPager(content string) {
-	lines := strings.Count(content, "\n")
-
-	// check if stdout is a terminal and content is long
-	if term.IsTerminal(int(os.Stdout.Fd())) {
-		_, height, err := term.GetSize(int(os.Stdout.Fd()))
-		if err == nil && lines > height-2 {
-			// use pager
-			pager := os.Getenv("PAGER")
-			if pager == "" {
-				pager = "less"
-			}
-
-			cmd := exec.Command(pager, "-R") // -R for color support
-			cmd.Stdout = os.Stdout
-			cmd.Stderr = os.Stderr
-
-			stdin, err := cmd.StdinPipe()
-			if err != nil {
-				fmt.Print(content)
-				return
-			}
-
-			if err := cmd.Start(); err != nil {
-				fmt.Print(content)
-				return
-			}
-
-			_, _ = io.WriteString(stdin, content)
-			_ = stdin.Close()
-			_ = cmd.Wait()
-			return
-		}
+// wideRows maps connections to their printable fields with numeric
+// addresses, the hostnames go in lhost and rhost
+func wideRows(conns []collector.Connection) []map[string]string {
+	rows := connectionRows(conns)
+	for i, c := range conns {
+		rows[i]["laddr"] = c.Laddr
+		rows[i]["raddr"] = c.Raddr
 	}
-
-	fmt.Print(content)
+	return rows
 }
 
 func init() {
@@ -388,16 +337,16 @@ func init() {
 
 	cfg := config.Get()
 
+	// shared output flags
+	addOutputFlags(lsCmd, &outputFormat, cfg.Defaults.OutputFormat)
+
 	// ls-specific flags
-	lsCmd.Flags().StringVarP(&outputFormat, "output", "o", cfg.Defaults.OutputFormat, "Output format (table, wide, json, csv)")
-	lsCmd.Flags().BoolVar(&noHeaders, "no-headers", cfg.Defaults.NoHeaders, "Omit headers for table/csv output")
 	lsCmd.Flags().BoolVar(&showTimestamp, "ts", false, "Include timestamp in output")
-	lsCmd.Flags().StringVarP(&sortBy, "sort", "s", cfg.Defaults.SortBy, "Sort by column (e.g., pid:desc)")
-	lsCmd.Flags().StringVarP(&fields, "fields", "f", strings.Join(cfg.Defaults.Fields, ","), "Comma-separated list of fields to show")
-	lsCmd.Flags().StringVar(&colorMode, "color", cfg.Defaults.Color, "Color mode (auto, always, never)")
+	lsCmd.Flags().StringVarP(&sortBy, "sort", "s", cfg.Defaults.SortBy, "Sort by columns (e.g., pid:desc or state,process,lport:desc)")
 	lsCmd.Flags().BoolVarP(&numeric, "numeric", "n", cfg.Defaults.Numeric, "Don't resolve hostnames")
 	lsCmd.Flags().BoolVarP(&plainOutput, "plain", "p", false, "Plain output (parsable, no styling)")
+	lsCmd.Flags().StringVar(&groupBy, "group-by", "", "Aggregate connections by comma-separated fields (e.g., raddr,process)")
 
 	// shared filter flags
 	addFilterFlags(lsCmd)
-}
\ No newline at end of file
+}
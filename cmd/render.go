@@ -0,0 +1,614 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"text/template"
+	"unicode/utf8"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/spf13/cobra"
+	"github.com/tidwall/pretty"
+	"golang.org/x/term"
+	"gopkg.in/yaml.v3"
+
+	"github.com/karol-broda/snitch/internal/color"
+	"github.com/karol-broda/snitch/internal/config"
+	"github.com/karol-broda/snitch/internal/units"
+)
+
+// Output is one batch of a command's output. structured formats and
+// templates render the records, tabular formats their rows.
+type Output struct {
+	// the records, e.g. connections, trace events or stats samples
+	Items []any
+
+	// Value is what json and yaml marshal, Items when nil
+	Value any
+
+	// the columns and rows of tabular formats
+	Fields []string
+	Rows   []map[string]string
+
+	Headers bool
+
+	// FieldHeaders prints the header row of plain, csv, tsv and markdown
+	// output as the field names instead of upper-casing them, which is
+	// what stats has always printed
+	FieldHeaders bool
+
+	// Continued marks the batches of a stream after the first, which do
+	// not repeat the headers of csv, tsv, plain and markdown output
+	Continued bool
+
+	// Stream marks commands that render batch after batch. json writes
+	// each batch on one line and tables are not paged.
+	Stream bool
+
+	// Summary is printed below styled tables, e.g. "3 connections"
+	Summary string
+}
+
+// Renderer writes Output in one format
+type Renderer interface {
+	Render(w io.Writer, out Output) error
+}
+
+// RendererFunc adapts a function to a Renderer
+type RendererFunc func(w io.Writer, out Output) error
+
+func (f RendererFunc) Render(w io.Writer, out Output) error {
+	return f(w, out)
+}
+
+// renderers holds the output formats every command supports, by -o name
+var renderers = map[string]Renderer{
+	"table":    RendererFunc(renderTable),
+	"wide":     RendererFunc(renderTable),
+	"plain":    RendererFunc(renderPlain),
+	"json":     RendererFunc(renderJSON),
+	"ndjson":   RendererFunc(renderNDJSON),
+	"csv":      RendererFunc(renderCSV),
+	"tsv":      RendererFunc(renderTSV),
+	"yaml":     RendererFunc(renderYAML),
+	"markdown": RendererFunc(renderMarkdown),
+}
+
+// OutputFormats lists the values -o accepts
+func OutputFormats() []string {
+	formats := make([]string, 0, len(renderers)+2)
+	for name := range renderers {
+		formats = append(formats, name)
+	}
+	sort.Strings(formats)
+	return append(formats, "template=TEXT", "template-file=PATH")
+}
+
+// rendererFor returns the renderer of an -o value. overrides replace
+// formats for one command, like the sectioned table of stats.
+func rendererFor(format string, overrides map[string]Renderer) (Renderer, error) {
+	if isTemplateFormat(format) {
+		tmpl, err := parseTemplateFormat(format)
+		if err != nil {
+			return nil, err
+		}
+		return templateRenderer{tmpl}, nil
+	}
+	if r, ok := overrides[format]; ok {
+		return r, nil
+	}
+	if r, ok := renderers[format]; ok {
+		return r, nil
+	}
+	return nil, fmt.Errorf("invalid output format: %s. valid formats are: %s", format, strings.Join(OutputFormats(), ", "))
+}
+
+// addOutputFlags adds the output flags every command shares. format is
+// the command's own -o variable, so defaults can differ between commands.
+func addOutputFlags(cmd *cobra.Command, format *string, defaultFormat string) {
+	cfg := config.Get()
+
+	cmd.Flags().StringVarP(format, "output", "o", defaultFormat, "Output format ("+strings.Join(OutputFormats(), ", ")+")")
+	cmd.Flags().BoolVar(&noHeaders, "no-headers", cfg.Defaults.NoHeaders, "Omit headers for tabular output")
+	cmd.Flags().StringVarP(&fields, "fields", "f", "", "Comma-separated list of fields to show")
+	cmd.Flags().StringVar(&colorMode, "color", cfg.Defaults.Color, "Color mode (auto, always, never)")
+}
+
+// selectFields returns the --fields list, or defaults when it is empty
+func selectFields(defaults []string) []string {
+	if fields == "" {
+		return defaults
+	}
+	return strings.Split(fields, ",")
+}
+
+type templateRenderer struct {
+	tmpl *template.Template
+}
+
+func (r templateRenderer) Render(w io.Writer, out Output) error {
+	for _, item := range out.Items {
+		if err := executeTemplate(w, r.tmpl, item); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (out Output) value() any {
+	if out.Value != nil {
+		return out.Value
+	}
+	return out.Items
+}
+
+// writeHeaders reports whether a tabular format prints its header row
+func (out Output) writeHeaders() bool {
+	return out.Headers && !out.Continued
+}
+
+func renderTable(w io.Writer, out Output) error {
+	table := styledTable(humanRows(out.Rows), out.Headers, out.Fields, out.Summary, terminalWidth(w))
+	if w == os.Stdout && !out.Stream {
+		printWithPager(table)
+		return nil
+	}
+	_, err := io.WriteString(w, table)
+	return err
+}
+
+func renderPlain(w io.Writer, out Output) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 3, ' ', 0)
+
+	if out.writeHeaders() {
+		fmt.Fprintln(tw, strings.Join(out.headerRow(), "\t"))
+	}
+	for _, row := range out.Rows {
+		fmt.Fprintln(tw, strings.Join(rowValues(row, out.Fields), "\t"))
+	}
+	return tw.Flush()
+}
+
+func renderCSV(w io.Writer, out Output) error {
+	writer := csv.NewWriter(w)
+
+	if out.writeHeaders() {
+		_ = writer.Write(out.headerRow())
+	}
+	for _, row := range out.Rows {
+		_ = writer.Write(rowValues(row, out.Fields))
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// tsvEscaper keeps values on one line and in their column
+var tsvEscaper = strings.NewReplacer("\t", " ", "\n", " ", "\r", " ")
+
+func renderTSV(w io.Writer, out Output) error {
+	var b strings.Builder
+
+	if out.writeHeaders() {
+		b.WriteString(strings.Join(out.headerRow(), "\t") + "\n")
+	}
+	for _, row := range out.Rows {
+		values := rowValues(row, out.Fields)
+		for i, v := range values {
+			values[i] = tsvEscaper.Replace(v)
+		}
+		b.WriteString(strings.Join(values, "\t") + "\n")
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// markdownEscaper keeps values inside their cell
+var markdownEscaper = strings.NewReplacer("|", "\\|", "\n", " ")
+
+func renderMarkdown(w io.Writer, out Output) error {
+	var b strings.Builder
+
+	if out.writeHeaders() {
+		b.WriteString("| " + strings.Join(out.headerRow(), " | ") + " |\n")
+		b.WriteString("|" + strings.Repeat(" --- |", len(out.Fields)) + "\n")
+	}
+	for _, row := range humanRows(out.Rows) {
+		values := rowValues(row, out.Fields)
+		for i, v := range values {
+			values[i] = markdownEscaper.Replace(v)
+		}
+		b.WriteString("| " + strings.Join(values, " | ") + " |\n")
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func renderJSON(w io.Writer, out Output) error {
+	// streams print one batch per line
+	if out.Stream {
+		data, err := json.Marshal(out.value())
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(w, string(data))
+		return err
+	}
+
+	data, err := json.MarshalIndent(out.value(), "", "  ")
+	if err != nil {
+		return err
+	}
+	if !color.IsColorDisabled() {
+		data = pretty.Color(data, nil)
+	}
+	_, err = fmt.Fprintln(w, string(data))
+	return err
+}
+
+func renderNDJSON(w io.Writer, out Output) error {
+	for _, item := range out.Items {
+		data, err := json.Marshal(item)
+		if err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintln(w, string(data)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// renderYAML converts the json encoding to yaml, so keys keep the names
+// and order of the json output
+func renderYAML(w io.Writer, out Output) error {
+	data, err := json.Marshal(out.value())
+	if err != nil {
+		return err
+	}
+
+	var node yaml.Node
+	if err := yaml.Unmarshal(data, &node); err != nil {
+		return err
+	}
+	blockStyle(&node)
+
+	var b bytes.Buffer
+	if out.Continued {
+		b.WriteString("---\n")
+	}
+	enc := yaml.NewEncoder(&b)
+	enc.SetIndent(2)
+	if err := enc.Encode(&node); err != nil {
+		return err
+	}
+	if err := enc.Close(); err != nil {
+		return err
+	}
+	_, err = w.Write(b.Bytes())
+	return err
+}
+
+// blockStyle drops the flow style and quotes yaml parsed from json
+func blockStyle(node *yaml.Node) {
+	node.Style = 0
+	for _, child := range node.Content {
+		blockStyle(child)
+	}
+}
+
+// byteFields hold byte counts, and rateFields bytes per second. the
+// formats people read show them in the configured units, parsable
+// formats keep the raw numbers.
+var (
+	byteFields = []string{"rx_bytes", "tx_bytes"}
+	rateFields = []string{"rx/s", "tx/s"}
+)
+
+// humanRows returns rows with byte counts and rates humanized
+func humanRows(rows []map[string]string) []map[string]string {
+	mode := config.Get().Defaults.Units
+	human := make([]map[string]string, len(rows))
+	for i, row := range rows {
+		h := make(map[string]string, len(row))
+		for k, v := range row {
+			h[k] = v
+		}
+		for _, f := range byteFields {
+			if n, err := strconv.ParseInt(row[f], 10, 64); err == nil {
+				h[f] = units.FormatBytes(n, mode)
+			}
+		}
+		for _, f := range rateFields {
+			if n, err := strconv.ParseFloat(row[f], 64); err == nil {
+				h[f] = units.FormatRate(n, mode)
+			}
+		}
+		human[i] = h
+	}
+	return human
+}
+
+// headerRow returns the header row of tabular formats
+func (out Output) headerRow() []string {
+	if out.FieldHeaders {
+		return out.Fields
+	}
+	return upperFields(out.Fields)
+}
+
+func upperFields(fields []string) []string {
+	upper := make([]string, len(fields))
+	for i, f := range fields {
+		upper[i] = strings.ToUpper(f)
+	}
+	return upper
+}
+
+func rowValues(row map[string]string, fields []string) []string {
+	values := make([]string, len(fields))
+	for i, f := range fields {
+		values[i] = row[f]
+	}
+	return values
+}
+
+// styledTable draws rows as a bordered table with summary below it. with
+// maxWidth set the widest columns shrink until the table fits and their
+// values wrap onto more lines; without it columns are as wide as their
+// values.
+func styledTable(rows []map[string]string, headers bool, selectedFields []string, summary string, maxWidth int) string {
+	// calculate column widths
+	widths := make(map[string]int)
+	for _, f := range selectedFields {
+		widths[f] = len(strings.ToUpper(f))
+	}
+
+	for _, fm := range rows {
+		for _, f := range selectedFields {
+			if n := utf8.RuneCountInString(fm[f]); n > widths[f] {
+				widths[f] = n
+			}
+		}
+	}
+
+	// pad widths
+	for f := range widths {
+		widths[f] += 2 // padding
+	}
+
+	if maxWidth > 0 {
+		fitWidths(widths, selectedFields, maxWidth)
+	}
+
+	// build output
+	var output strings.Builder
+
+	// styles
+	borderStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("240"))
+	headerStyle := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("15"))
+	faintStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("245"))
+
+	// build top border
+	output.WriteString("\n")
+	output.WriteString(borderStyle.Render("  ╭"))
+	for i, f := range selectedFields {
+		if i > 0 {
+			output.WriteString(borderStyle.Render("┬"))
+		}
+		output.WriteString(borderStyle.Render(strings.Repeat("─", widths[f])))
+	}
+	output.WriteString(borderStyle.Render("╮"))
+	output.WriteString("\n")
+
+	// header row
+	if headers {
+		output.WriteString(borderStyle.Render("  │"))
+		for i, f := range selectedFields {
+			if i > 0 {
+				output.WriteString(borderStyle.Render("│"))
+			}
+			cell := fmt.Sprintf(" %-*s", widths[f]-1, strings.ToUpper(f))
+			output.WriteString(headerStyle.Render(cell))
+		}
+		output.WriteString(borderStyle.Render("│"))
+		output.WriteString("\n")
+
+		// header separator
+		output.WriteString(borderStyle.Render("  ├"))
+		for i, f := range selectedFields {
+			if i > 0 {
+				output.WriteString(borderStyle.Render("┼"))
+			}
+			output.WriteString(borderStyle.Render(strings.Repeat("─", widths[f])))
+		}
+		output.WriteString(borderStyle.Render("┤"))
+		output.WriteString("\n")
+	}
+
+	// data rows, one line per wrapped part of the longest value
+	for _, fm := range rows {
+		cells := make([][]string, len(selectedFields))
+		lines := 1
+		for i, f := range selectedFields {
+			cells[i] = wrapCell(fm[f], widths[f]-2)
+			lines = max(lines, len(cells[i]))
+		}
+
+		for line := 0; line < lines; line++ {
+			output.WriteString(borderStyle.Render("  │"))
+			for i, f := range selectedFields {
+				if i > 0 {
+					output.WriteString(borderStyle.Render("│"))
+				}
+				val := ""
+				if line < len(cells[i]) {
+					val = cells[i][line]
+				}
+				cell := fmt.Sprintf(" %-*s ", widths[f]-2, val)
+
+				if style, ok := cellStyle(f, fm); ok {
+					output.WriteString(style.Render(cell))
+				} else {
+					output.WriteString(cell)
+				}
+			}
+			output.WriteString(borderStyle.Render("│"))
+			output.WriteString("\n")
+		}
+	}
+
+	// bottom border
+	output.WriteString(borderStyle.Render("  ╰"))
+	for i, f := range selectedFields {
+		if i > 0 {
+			output.WriteString(borderStyle.Render("┴"))
+		}
+		output.WriteString(borderStyle.Render(strings.Repeat("─", widths[f])))
+	}
+	output.WriteString(borderStyle.Render("╯"))
+	output.WriteString("\n")
+
+	// summary
+	output.WriteString(faintStyle.Render("  " + summary + "\n"))
+	output.WriteString("\n")
+
+	return output.String()
+}
+
+// fitWidths narrows the widest columns, one at a time, until the table
+// fits maxWidth. no column gets narrower than its header.
+func fitWidths(widths map[string]int, fields []string, maxWidth int) {
+	// indent, outer borders and the separators between columns
+	total := 3 + len(fields)
+	for _, f := range fields {
+		total += widths[f]
+	}
+
+	for total > maxWidth {
+		widest := ""
+		for _, f := range fields {
+			if widths[f] > len(f)+2 && (widest == "" || widths[f] > widths[widest]) {
+				widest = f
+			}
+		}
+		if widest == "" {
+			return // spill past the terminal
+		}
+		widths[widest]--
+		total--
+	}
+}
+
+// wrapCell splits val into lines of at most width characters
+func wrapCell(val string, width int) []string {
+	runes := []rune(val)
+	if width <= 0 || len(runes) <= width {
+		return []string{val}
+	}
+
+	var lines []string
+	for len(runes) > width {
+		lines = append(lines, string(runes[:width]))
+		runes = runes[width:]
+	}
+	return append(lines, string(runes))
+}
+
+// cellStyle colors the proto, state and process columns
+func cellStyle(field string, fm map[string]string) (lipgloss.Style, bool) {
+	switch field {
+	case "proto":
+		c := lipgloss.Color("37") // cyan
+		switch {
+		case strings.Contains(fm["proto"], "udp"):
+			c = lipgloss.Color("135") // purple
+		case strings.HasPrefix(fm["proto"], "raw"), fm["proto"] == "packet":
+			c = lipgloss.Color("160") // red
+		}
+		return lipgloss.NewStyle().Foreground(c), true
+	case "state":
+		c := lipgloss.Color("245") // gray
+		switch strings.ToUpper(fm["state"]) {
+		case "LISTEN":
+			c = lipgloss.Color("35") // green
+		case "ESTABLISHED":
+			c = lipgloss.Color("33") // blue
+		case "TIME_WAIT", "CLOSE_WAIT":
+			c = lipgloss.Color("178") // yellow
+		}
+		return lipgloss.NewStyle().Foreground(c), true
+	case "process":
+		return lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("15")), true
+	default:
+		return lipgloss.Style{}, false
+	}
+}
+
+// terminalWidth is the width tables fit to, 0 when w is not a terminal
+func terminalWidth(w io.Writer) int {
+	f, ok := w.(*os.File)
+	if !ok || !term.IsTerminal(int(f.Fd())) {
+		return 0
+	}
+	width, _, err := term.GetSize(int(f.Fd()))
+	if err != nil {
+		return 0
+	}
+	return width
+}
+
+func printWithPager(content string) {
+	lines := strings.Count(content, "\n")
+
+	// check if stdout is a terminal and content is long
+	if term.IsTerminal(int(os.Stdout.Fd())) {
+		_, height, err := term.GetSize(int(os.Stdout.Fd()))
+		if err == nil && lines > height-2 {
+			// use pager
+			pager := os.Getenv("PAGER")
+			if pager == "" {
+				pager = "less"
+			}
+
+			cmd := exec.Command(pager, "-R") // -R for color support
+			cmd.Stdout = os.Stdout
+			cmd.Stderr = os.Stderr
+
+			stdin, err := cmd.StdinPipe()
+			if err != nil {
+				fmt.Print(content)
+				return
+			}
+
+			if err := cmd.Start(); err != nil {
+				fmt.Print(content)
+				return
+			}
+
+			_, _ = io.WriteString(stdin, content)
+			_ = stdin.Close()
+			_ = cmd.Wait()
+			return
+		}
+	}
+
+	fmt.Print(content)
+}
+
+// toItems converts records to the Items of an Output
+func toItems[T any](records []T) []any {
+	items := make([]any, len(records))
+	for i, r := range records {
+		items[i] = r
+	}
+	return items
+}
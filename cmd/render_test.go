@@ -0,0 +1,260 @@
+package cmd
+
+import (
+	"io"
+	"regexp"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/karol-broda/snitch/internal/collector"
+	"github.com/karol-broda/snitch/internal/color"
+)
+
+func TestRenderers(t *testing.T) {
+	color.Init("never")
+	defer color.Init("auto")
+
+	conns := []collector.Connection{
+		{PID: 1, Process: "nginx", Proto: "tcp", Lport: 80},
+		{PID: 2, Process: "my|app", Proto: "udp", Lport: 53},
+	}
+	rows := []map[string]string{
+		{"pid": "1", "process": "nginx", "lport": "80"},
+		{"pid": "2", "process": "my|app", "lport": "53"},
+	}
+	out := Output{
+		Items:   toItems(conns),
+		Value:   conns,
+		Fields:  []string{"pid", "process"},
+		Rows:    rows,
+		Headers: true,
+	}
+
+	fieldHeaders := out
+	fieldHeaders.FieldHeaders = true
+
+	tests := []struct {
+		format   string
+		out      Output
+		expected string
+		contains []string
+	}{
+		{format: "csv", out: out, expected: "PID,PROCESS\n1,nginx\n2,my|app\n"},
+		{format: "csv", out: fieldHeaders, expected: "pid,process\n1,nginx\n2,my|app\n"},
+		{format: "tsv", out: out, expected: "PID\tPROCESS\n1\tnginx\n2\tmy|app\n"},
+		{format: "plain", out: out, expected: "PID   PROCESS\n1     nginx\n2     my|app\n"},
+		{format: "markdown", out: out, expected: "| PID | PROCESS |\n| --- | --- |\n| 1 | nginx |\n| 2 | my\\|app |\n"},
+		{format: "ndjson", out: out, contains: []string{`"process":"nginx"`, `"process":"my|app"`}},
+		{format: "json", out: out, contains: []string{"[\n  {", `"process": "nginx"`}},
+		{format: "yaml", out: out, contains: []string{"- ts:", "  process: nginx\n", "  lport: 53\n"}},
+		{format: "table", out: Output{Fields: out.Fields, Rows: rows, Headers: true, Stream: true, Summary: "2 connections"},
+			contains: []string{"PROCESS", "nginx", "2 connections"}},
+		{format: "template={{.Process}}:{{.Lport}}", out: out, expected: "nginx:80\nmy|app:53\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			r, err := rendererFor(tt.format, nil)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			var b strings.Builder
+			if err := r.Render(&b, tt.out); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if tt.expected != "" && b.String() != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, b.String())
+			}
+			for _, s := range tt.contains {
+				if !strings.Contains(b.String(), s) {
+					t.Errorf("expected output to contain %q, got %q", s, b.String())
+				}
+			}
+		})
+	}
+}
+
+func TestRenderers_Units(t *testing.T) {
+	color.Init("never")
+	defer color.Init("auto")
+
+	out := Output{
+		Fields:  []string{"rx_bytes", "rx/s"},
+		Rows:    []map[string]string{{"rx_bytes": "1536", "rx/s": "2048"}},
+		Headers: true,
+		Stream:  true,
+	}
+
+	tests := []struct {
+		format   string
+		contains string
+	}{
+		{"table", "1.5KiB"},
+		{"table", "2.0KiB/s"},
+		{"markdown", "| 1.5KiB | 2.0KiB/s |"},
+		{"csv", "1536,2048"},
+		{"tsv", "1536\t2048"},
+		{"plain", "1536"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			r, err := rendererFor(tt.format, nil)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			var b strings.Builder
+			if err := r.Render(&b, out); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !strings.Contains(b.String(), tt.contains) {
+				t.Errorf("expected output to contain %q, got %q", tt.contains, b.String())
+			}
+		})
+	}
+}
+
+func TestRenderers_Stream(t *testing.T) {
+	color.Init("never")
+	defer color.Init("auto")
+
+	event := TraceEvent{Event: "opened", Connection: collector.Connection{Process: "curl"}}
+	out := Output{
+		Items:     []any{event},
+		Value:     event,
+		Fields:    []string{"event", "process"},
+		Rows:      []map[string]string{{"event": "opened", "process": "curl"}},
+		Headers:   true,
+		Continued: true,
+		Stream:    true,
+	}
+
+	tests := []struct {
+		format   string
+		expected string
+	}{
+		// later batches of a stream do not repeat headers
+		{"csv", "opened,curl\n"},
+		{"tsv", "opened\tcurl\n"},
+		{"markdown", "| opened | curl |\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			r, _ := rendererFor(tt.format, nil)
+			var b strings.Builder
+			if err := r.Render(&b, out); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if b.String() != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, b.String())
+			}
+		})
+	}
+
+	// streamed json is one object per line
+	r, _ := rendererFor("json", nil)
+	var b strings.Builder
+	if err := r.Render(&b, out); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Count(b.String(), "\n") != 1 || !strings.HasPrefix(b.String(), `{"ts":`) {
+		t.Errorf("expected one compact json object, got %q", b.String())
+	}
+}
+
+func TestRenderStatsTable_Continued(t *testing.T) {
+	stats := &StatsData{Total: 1, ByProto: map[string]int{"tcp": 1}}
+	out := Output{Items: []any{stats}, Value: stats, Headers: true}
+
+	var first, later strings.Builder
+	if err := renderStatsTable(&first, out); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	out.Continued = true
+	if err := renderStatsTable(&later, out); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !strings.Contains(first.String(), "TOTAL CONNECTIONS") {
+		t.Errorf("expected headers in the first sample, got %q", first.String())
+	}
+	if strings.Contains(later.String(), "TOTAL CONNECTIONS") || !strings.Contains(later.String(), "TCP") {
+		t.Errorf("expected later samples without headers, got %q", later.String())
+	}
+}
+
+func TestRendererFor(t *testing.T) {
+	if _, err := rendererFor("bogus", nil); err == nil {
+		t.Error("expected an error for an unknown format")
+	}
+	if _, err := rendererFor("template={{.Process", nil); err == nil {
+		t.Error("expected an error for an invalid template")
+	}
+
+	called := false
+	override := RendererFunc(func(w io.Writer, out Output) error {
+		called = true
+		return nil
+	})
+	r, err := rendererFor("table", map[string]Renderer{"table": override})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	_ = r.Render(io.Discard, Output{})
+	if !called {
+		t.Error("expected the command's table renderer to replace the shared one")
+	}
+
+	for _, format := range []string{"table", "plain", "json", "ndjson", "csv", "tsv", "yaml", "markdown"} {
+		if _, err := rendererFor(format, nil); err != nil {
+			t.Errorf("expected format %s to be registered: %v", format, err)
+		}
+	}
+}
+
+func TestStyledTable(t *testing.T) {
+	long := strings.Repeat("x", 40)
+	rows := []map[string]string{{"pid": "1", "cmdline": long}}
+	fields := []string{"pid", "cmdline"}
+
+	// without a terminal width values are never cut
+	table := stripANSI(styledTable(rows, true, fields, "1 connections", 0))
+	if !strings.Contains(table, long) {
+		t.Errorf("expected the full value, got:\n%s", table)
+	}
+
+	// at a width values wrap and every line fits
+	table = stripANSI(styledTable(rows, true, fields, "1 connections", 30))
+	for _, line := range strings.Split(table, "\n") {
+		if n := utf8.RuneCountInString(line); n > 30 {
+			t.Errorf("line wider than 30 columns (%d): %q", n, line)
+		}
+	}
+	if strings.Count(table, "x") != 40 {
+		t.Errorf("expected every character of the wrapped value, got:\n%s", table)
+	}
+	if !strings.Contains(table, "CMDLINE") {
+		t.Errorf("expected headers to stay whole, got:\n%s", table)
+	}
+}
+
+func TestWideFields(t *testing.T) {
+	got := wideFields([]string{"pid", "process", "user", "laddr", "lport", "raddr", "rport"})
+	expected := []string{"pid", "process", "user", "laddr", "lhost", "lport", "raddr", "rhost", "rport",
+		"uid", "ipversion", "if", "inode", "namespace", "rtt_ms", "rx_bytes", "tx_bytes"}
+
+	if strings.Join(got, ",") != strings.Join(expected, ",") {
+		t.Errorf("expected %v, got %v", expected, got)
+	}
+}
+
+var ansiPattern = regexp.MustCompile(`\x1b\[[0-9;]*m`)
+
+func stripANSI(s string) string {
+	return ansiPattern.ReplaceAllString(s, "")
+}
@@ -3,13 +3,17 @@ package cmd
 import (
 	"fmt"
 	"os"
+	"github.com/karol-broda/snitch/internal/collector"
 	"github.com/karol-broda/snitch/internal/config"
 
 	"github.com/spf13/cobra"
 )
 
 var (
-	cfgFile string
+	cfgFile          string
+	collectorBackend string
+	procRoot         string
+	debugMode        bool
 )
 
 var rootCmd = &cobra.Command{
@@ -19,9 +23,33 @@ var rootCmd = &cobra.Command{
 
 A modern, unix-y tool for inspecting network connections, with a focus on a clear usage API and a solid testing strategy.`,
 	PersistentPreRun: func(cmd *cobra.Command, args []string) {
-		if _, err := config.Load(); err != nil {
+		cfg, err := config.Load()
+		if err != nil {
 			fmt.Fprintf(os.Stderr, "Warning: Error loading config: %v\n", err)
+			cfg = config.Get()
 		}
+
+		debugMode, _ = cmd.Flags().GetBool("debug")
+
+		root := cfg.Defaults.ProcRoot
+		if cmd.Flags().Changed("proc-root") {
+			root = procRoot
+		}
+		if err := collector.SetProcRoot(root); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		backend := cfg.Defaults.Collector
+		if cmd.Flags().Changed("collector") {
+			backend = collectorBackend
+		}
+		c, err := collector.NewCollector(backend)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		collector.SetCollector(c)
 	},
 	Run: func(cmd *cobra.Command, args []string) {
 		// default to top - flags are shared so they work here too
@@ -42,6 +70,8 @@ func init() {
 
 	// add top's flags to root so `snitch -l` works (defaults to top command)
 	cfg := config.Get()
+	rootCmd.PersistentFlags().StringVar(&collectorBackend, "collector", cfg.Defaults.Collector, "Connection collector backend (auto, netlink, proc)")
+	rootCmd.PersistentFlags().StringVar(&procRoot, "proc-root", cfg.Defaults.ProcRoot, "Path the proc filesystem is mounted at, e.g. /host/proc")
 	rootCmd.Flags().StringVar(&topTheme, "theme", cfg.Defaults.Theme, "Theme for TUI (dark, light, mono, auto)")
 	rootCmd.Flags().DurationVarP(&topInterval, "interval", "i", 0, "Refresh interval (default 1s)")
 
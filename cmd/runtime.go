@@ -4,7 +4,9 @@ import (
 	"fmt"
 	"github.com/karol-broda/snitch/internal/collector"
 	"github.com/karol-broda/snitch/internal/color"
-	"strconv"
+	"github.com/karol-broda/snitch/internal/config"
+	"os"
+	"regexp"
 	"strings"
 
 	"github.com/spf13/cobra"
@@ -28,10 +30,14 @@ type Runtime struct {
 var (
 	filterTCP    bool
 	filterUDP    bool
+	filterUnix   bool
 	filterListen bool
 	filterEstab  bool
 	filterIPv4   bool
 	filterIPv6   bool
+
+	filterExclude []string
+	noExclude     bool
 )
 
 // BuildFilters constructs FilterOptions from command args and shortcut flags.
@@ -45,11 +51,20 @@ func BuildFilters(args []string) (collector.FilterOptions, error) {
 	filters.IPv4 = filterIPv4
 	filters.IPv6 = filterIPv6
 
-	// apply protocol shortcut flags
-	if filterTCP && !filterUDP {
-		filters.Proto = "tcp"
-	} else if filterUDP && !filterTCP {
-		filters.Proto = "udp"
+	// apply protocol shortcut flags. -t with -u keeps every network
+	// protocol, adding -x narrows that to the listed ones
+	var protos []string
+	if filterTCP {
+		protos = append(protos, "tcp")
+	}
+	if filterUDP {
+		protos = append(protos, "udp")
+	}
+	if filterUnix {
+		protos = append(protos, "unix")
+	}
+	if len(protos) == 1 || filterUnix {
+		filters.Proto = strings.Join(protos, ",")
 	}
 
 	// apply state shortcut flags
@@ -59,12 +74,43 @@ func BuildFilters(args []string) (collector.FilterOptions, error) {
 		filters.State = "ESTABLISHED"
 	}
 
+	filters.Exclude, err = BuildExcludes()
+	if err != nil {
+		return filters, err
+	}
+
 	return filters, nil
 }
 
+// BuildExcludes compiles the --exclude flags and, unless --no-exclude is
+// set, the exclude list of the config file
+func BuildExcludes() ([]collector.Expr, error) {
+	var entries []string
+	if !noExclude {
+		entries = append(entries, config.Get().Defaults.Exclude...)
+	}
+	entries = append(entries, filterExclude...)
+
+	var excludes []collector.Expr
+	for _, entry := range entries {
+		expr, err := collector.ParseExclude(entry)
+		if err != nil {
+			return nil, fmt.Errorf("invalid exclude %q: %w", entry, err)
+		}
+		excludes = append(excludes, expr)
+	}
+	return excludes, nil
+}
+
 // FetchConnections gets connections from the collector and applies filters.
+// unix domain sockets are only fetched when the filters ask for them.
 func FetchConnections(filters collector.FilterOptions) ([]collector.Connection, error) {
-	connections, err := collector.GetConnections()
+	fetch := collector.GetConnections
+	if filters.WantsUnix() {
+		fetch = collector.GetAllConnections
+	}
+
+	connections, err := fetch()
 	if err != nil {
 		return nil, err
 	}
@@ -72,6 +118,30 @@ func FetchConnections(filters collector.FilterOptions) ([]collector.Connection,
 	return collector.FilterConnections(connections, filters), nil
 }
 
+// lastWarning is the diagnostics warning printed last, so commands that
+// collect repeatedly only warn again when it changes
+var lastWarning string
+
+// reportDiagnostics tells the user on stderr what the last collection could
+// not see: a one-line warning normally, every finding with --debug.
+func reportDiagnostics() {
+	diag := collector.GetDiagnostics()
+
+	if debugMode {
+		for _, line := range diag.Details() {
+			fmt.Fprintln(os.Stderr, "debug: "+line)
+		}
+		return
+	}
+
+	warning := diag.Warning()
+	if warning == "" || warning == lastWarning {
+		return
+	}
+	lastWarning = warning
+	fmt.Fprintln(os.Stderr, "warning: "+warning)
+}
+
 // NewRuntime creates a runtime with fetched and filtered connections.
 func NewRuntime(args []string, colorMode string, numeric bool) (*Runtime, error) {
 	color.Init(colorMode)
@@ -99,103 +169,104 @@ func (r *Runtime) SortConnections(opts collector.SortOptions) {
 	collector.SortConnections(r.Connections, opts)
 }
 
-// ParseFilterArgs parses key=value filter arguments.
+// ParseFilterArgs parses filter arguments, joined with spaces, as a filter
+// expression. an argument that is a single key=value pair with spaces in
+// its value, like proc=my app, has the value quoted first.
 // exported for testing.
 func ParseFilterArgs(args []string) (collector.FilterOptions, error) {
-	filters := collector.FilterOptions{}
-	for _, arg := range args {
-		parts := strings.SplitN(arg, "=", 2)
-		if len(parts) != 2 {
-			return filters, fmt.Errorf("invalid filter format: %s (expected key=value)", arg)
-		}
-		key, value := parts[0], parts[1]
-		if err := applyFilter(&filters, key, value); err != nil {
-			return filters, err
-		}
+	args, err := expandQueries(args)
+	if err != nil {
+		return collector.FilterOptions{}, err
 	}
-	return filters, nil
+
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		quoted[i] = quotePairValue(arg)
+	}
+	return collector.ParseFilter(strings.Join(quoted, " "))
 }
 
-// applyFilter applies a single key=value filter to FilterOptions.
-func applyFilter(filters *collector.FilterOptions, key, value string) error {
-	switch strings.ToLower(key) {
-	case "proto":
-		filters.Proto = value
-	case "state":
-		filters.State = value
-	case "pid":
-		pid, err := strconv.Atoi(value)
-		if err != nil {
-			return fmt.Errorf("invalid pid value: %s", value)
+// pairArg matches an argument made of one comparison, like proc=my app
+var pairArg = regexp.MustCompile(`^(!?[A-Za-z0-9_]+(?:!=|!~|=|~))(.*\s.*)$`)
+
+// quotePairValue quotes the value of a key=value argument whose value has
+// spaces, unless the argument already parses as an expression, like
+// proto=tcp state=listen, or the value looks like more expression:
+// parentheses, quotes or the keywords and, or, not and in
+func quotePairValue(arg string) string {
+	m := pairArg.FindStringSubmatch(arg)
+	if m == nil || strings.ContainsAny(m[2], `()"'`) {
+		return arg
+	}
+	if _, err := collector.ParseFilter(arg); err == nil {
+		return arg
+	}
+	for _, word := range strings.Fields(m[2]) {
+		switch strings.ToLower(word) {
+		case "and", "or", "not", "in":
+			return arg
 		}
-		filters.Pid = pid
-	case "proc":
-		filters.Proc = value
-	case "lport":
-		port, err := strconv.Atoi(value)
-		if err != nil {
-			return fmt.Errorf("invalid lport value: %s", value)
+	}
+	return m[1] + `"` + strings.ReplaceAll(m[2], `\`, `\\`) + `"`
+}
+
+// expandQueries replaces @name arguments with the filter of the saved query
+// of that name, parenthesized so it combines with the other arguments
+func expandQueries(args []string) ([]string, error) {
+	expanded := make([]string, 0, len(args))
+	for _, arg := range args {
+		if !strings.HasPrefix(arg, "@") {
+			expanded = append(expanded, arg)
+			continue
 		}
-		filters.Lport = port
-	case "rport":
-		port, err := strconv.Atoi(value)
-		if err != nil {
-			return fmt.Errorf("invalid rport value: %s", value)
+
+		q, ok := config.Get().Query(arg[1:])
+		if !ok {
+			return nil, fmt.Errorf("unknown saved query %q", arg)
 		}
-		filters.Rport = port
-	case "user":
-		uid, err := strconv.Atoi(value)
-		if err == nil {
-			filters.UID = uid
-		} else {
-			filters.User = value
+		if strings.TrimSpace(q.Filter) != "" {
+			expanded = append(expanded, "("+q.Filter+")")
 		}
-	case "laddr":
-		filters.Laddr = value
-	case "raddr":
-		filters.Raddr = value
-	case "contains":
-		filters.Contains = value
-	case "if", "interface":
-		filters.Interface = value
-	case "mark":
-		filters.Mark = value
-	case "namespace":
-		filters.Namespace = value
-	case "inode":
-		inode, err := strconv.ParseInt(value, 10, 64)
-		if err != nil {
-			return fmt.Errorf("invalid inode value: %s", value)
+	}
+	return expanded, nil
+}
+
+// savedQueries returns the saved queries args refer to as @name, in order
+func savedQueries(args []string) []config.Query {
+	var queries []config.Query
+	for _, arg := range args {
+		if !strings.HasPrefix(arg, "@") {
+			continue
 		}
-		filters.Inode = inode
-	case "since":
-		since, sinceRel, err := collector.ParseTimeFilter(value)
-		if err != nil {
-			return fmt.Errorf("invalid since value: %s", value)
+		if q, ok := config.Get().Query(arg[1:]); ok {
+			queries = append(queries, q)
 		}
-		filters.Since = since
-		filters.SinceRel = sinceRel
-	default:
-		return fmt.Errorf("unknown filter key: %s", key)
 	}
-	return nil
+	return queries
 }
 
 // FilterFlagsHelp returns the help text for common filter flags.
 const FilterFlagsHelp = `
-Filters are specified in key=value format. For example:
+Filters are key=value pairs, combined with and, or, not and parentheses.
+For example:
   snitch ls proto=tcp state=established
+  snitch ls 'proto=tcp and (lport in 80,443 or raddr ~ "^10\.") and not proc=chrome'
+
+Saved queries from the config file are run as @name, e.g. snitch ls @db.
 
 Available filters:
-  proto, state, pid, proc, lport, rport, user, laddr, raddr, contains, if, mark, namespace, inode, since`
+  proto, state, pid, proc, cmdline, lport, rport, user, laddr, raddr, contains, if, mark, namespace, container, inode, since`
 
 // addFilterFlags adds the common filter flags to a command.
 func addFilterFlags(cmd *cobra.Command) {
 	cmd.Flags().BoolVarP(&filterTCP, "tcp", "t", false, "Show only TCP connections")
 	cmd.Flags().BoolVarP(&filterUDP, "udp", "u", false, "Show only UDP connections")
+	cmd.Flags().BoolVarP(&filterUnix, "unix", "x", false, "Show only Unix domain sockets")
 	cmd.Flags().BoolVarP(&filterListen, "listen", "l", false, "Show only listening sockets")
 	cmd.Flags().BoolVarP(&filterEstab, "established", "e", false, "Show only established connections")
 	cmd.Flags().BoolVarP(&filterIPv4, "ipv4", "4", false, "Only show IPv4 connections")
 	cmd.Flags().BoolVarP(&filterIPv6, "ipv6", "6", false, "Only show IPv6 connections")
+	cmd.Flags().StringArrayVar(&filterExclude, "exclude", nil, "Hide connections of a process or matching a filter (repeatable)")
+	cmd.Flags().BoolVar(&noExclude, "no-exclude", false, "Ignore the exclude list of the config file")
 }
 
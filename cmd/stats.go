@@ -2,13 +2,15 @@ package cmd
 
 import (
 	"context"
-	"encoding/csv"
-	"encoding/json"
 	"fmt"
+	"io"
 	"log"
 	"os"
 	"os/signal"
 	"github.com/karol-broda/snitch/internal/collector"
+	"github.com/karol-broda/snitch/internal/color"
+	"github.com/karol-broda/snitch/internal/config"
+	"github.com/karol-broda/snitch/internal/units"
 	"sort"
 	"strconv"
 	"strings"
@@ -26,6 +28,13 @@ type StatsData struct {
 	ByState   map[string]int       `json:"by_state"`
 	ByProc    []ProcessStats       `json:"by_proc"`
 	ByIf      []InterfaceStats     `json:"by_if"`
+
+	// bytes of the connections, and their throughput since the previous
+	// sample with --interval
+	RxBytes int64   `json:"rx_bytes"`
+	TxBytes int64   `json:"tx_bytes"`
+	RxRate  float64 `json:"rx_rate,omitempty"`
+	TxRate  float64 `json:"tx_rate,omitempty"`
 }
 
 type ProcessStats struct {
@@ -44,7 +53,6 @@ var (
 	statsOutputFormat string
 	statsInterval     time.Duration
 	statsCount        int
-	statsNoHeaders    bool
 )
 
 var statsCmd = &cobra.Command{
@@ -52,8 +60,12 @@ var statsCmd = &cobra.Command{
 	Short: "Aggregated connection counters",
 	Long: `Aggregated connection counters.
 	
-Filters are specified in key=value format. For example:
+Filters are key=value pairs, combined with and, or, not and parentheses.
+For example:
   snitch stats proto=tcp state=listening
+  snitch stats 'proto=tcp and not lport in 22,53'
+
+Saved queries from the config file are run as @name, e.g. snitch stats @db.
 
 Available filters:
   proto, state, pid, proc, lport, rport, user, laddr, raddr, contains
@@ -80,9 +92,19 @@ func runStatsCommand(args []string) {
 		cancel()
 	}()
 
+	color.Init(colorMode)
+	renderer, err := rendererFor(statsOutputFormat, map[string]Renderer{
+		"table": RendererFunc(renderStatsTable),
+		"wide":  RendererFunc(renderStatsTable),
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	rates := collector.NewRateTracker()
 	count := 0
 	for {
-		stats, err := generateStats(filters)
+		stats, err := generateStats(filters, rates)
 		if err != nil {
 			log.Printf("Error generating stats: %v", err)
 			if statsCount > 0 || statsInterval == 0 {
@@ -92,14 +114,19 @@ func runStatsCommand(args []string) {
 			continue
 		}
 
-		switch statsOutputFormat {
-		case "json":
-			printStatsJSON(stats)
-		case "csv":
-			printStatsCSV(stats, !statsNoHeaders && count == 0)
-		default:
-			printStatsTable(stats, !statsNoHeaders && count == 0)
+		out := Output{
+			Items:        []any{stats},
+			Value:        stats,
+			Fields:       selectFields([]string{"timestamp", "metric", "key", "value"}),
+			Rows:         statsRows(stats),
+			Headers:      !noHeaders,
+			FieldHeaders: true,
+			Continued:    count > 0,
 		}
+		if err := renderer.Render(os.Stdout, out); err != nil {
+			log.Printf("Error rendering stats: %v", err)
+		}
+		reportDiagnostics()
 
 		count++
 		if statsCount > 0 && count >= statsCount {
@@ -119,11 +146,12 @@ func runStatsCommand(args []string) {
 	}
 }
 
-func generateStats(filters collector.FilterOptions) (*StatsData, error) {
+func generateStats(filters collector.FilterOptions, rates *collector.RateTracker) (*StatsData, error) {
 	filteredConnections, err := FetchConnections(filters)
 	if err != nil {
 		return nil, err
 	}
+	rates.Update(filteredConnections, time.Now())
 
 	stats := &StatsData{
 		Timestamp: time.Now(),
@@ -159,10 +187,15 @@ func generateStats(filters collector.FilterOptions) (*StatsData, error) {
 			}
 		}
 
-		// Count by interface (placeholder since we don't have interface data yet)
+		// Count by interface
 		if conn.Interface != "" {
 			ifCounts[conn.Interface]++
 		}
+
+		stats.RxBytes += conn.RxBytes
+		stats.TxBytes += conn.TxBytes
+		stats.RxRate += conn.RxRate
+		stats.TxRate += conn.TxRate
 	}
 
 	// Convert process map to sorted slice
@@ -187,51 +220,68 @@ func generateStats(filters collector.FilterOptions) (*StatsData, error) {
 	return stats, nil
 }
 
-func printStatsJSON(stats *StatsData) {
-	jsonOutput, err := json.MarshalIndent(stats, "", "  ")
-	if err != nil {
-		log.Printf("Error marshaling JSON: %v", err)
-		return
+// statsRows flattens a sample into one row per counter, for tabular formats
+func statsRows(stats *StatsData) []map[string]string {
+	ts := stats.Timestamp.Format(time.RFC3339)
+	row := func(metric, key string, value int) map[string]string {
+		return map[string]string{"timestamp": ts, "metric": metric, "key": key, "value": strconv.Itoa(value)}
 	}
-	fmt.Println(string(jsonOutput))
-}
-
-func printStatsCSV(stats *StatsData, headers bool) {
-	writer := csv.NewWriter(os.Stdout)
-	defer writer.Flush()
 
-	if headers {
-		_ = writer.Write([]string{"timestamp", "metric", "key", "value"})
+	rows := []map[string]string{
+		row("total", "", stats.Total),
+		{"timestamp": ts, "metric": "rx_bytes", "value": strconv.FormatInt(stats.RxBytes, 10)},
+		{"timestamp": ts, "metric": "tx_bytes", "value": strconv.FormatInt(stats.TxBytes, 10)},
 	}
-
-	ts := stats.Timestamp.Format(time.RFC3339)
-
-	_ = writer.Write([]string{ts, "total", "", strconv.Itoa(stats.Total)})
-
-	for proto, count := range stats.ByProto {
-		_ = writer.Write([]string{ts, "proto", proto, strconv.Itoa(count)})
+	if statsInterval > 0 {
+		rows = append(rows,
+			map[string]string{"timestamp": ts, "metric": "rx/s", "value": strconv.FormatFloat(stats.RxRate, 'f', 0, 64)},
+			map[string]string{"timestamp": ts, "metric": "tx/s", "value": strconv.FormatFloat(stats.TxRate, 'f', 0, 64)},
+		)
 	}
-
-	for state, count := range stats.ByState {
-		_ = writer.Write([]string{ts, "state", state, strconv.Itoa(count)})
+	for _, proto := range sortedKeys(stats.ByProto) {
+		rows = append(rows, row("proto", proto, stats.ByProto[proto]))
+	}
+	for _, state := range sortedKeys(stats.ByState) {
+		rows = append(rows, row("state", state, stats.ByState[state]))
 	}
-
 	for _, proc := range stats.ByProc {
-		_ = writer.Write([]string{ts, "process", proc.Process, strconv.Itoa(proc.Count)})
+		rows = append(rows, row("process", proc.Process, proc.Count))
 	}
-
 	for _, iface := range stats.ByIf {
-		_ = writer.Write([]string{ts, "interface", iface.Interface, strconv.Itoa(iface.Count)})
+		rows = append(rows, row("interface", iface.Interface, iface.Count))
+	}
+	return rows
+}
+
+func sortedKeys(m map[string]int) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
 	}
+	sort.Strings(keys)
+	return keys
 }
 
-func printStatsTable(stats *StatsData, headers bool) {
-	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+// renderStatsTable prints each sample as sections of counters
+func renderStatsTable(w io.Writer, out Output) error {
+	for _, item := range out.Items {
+		printStatsTable(w, item.(*StatsData), out.writeHeaders())
+	}
+	return nil
+}
+
+func printStatsTable(dst io.Writer, stats *StatsData, headers bool) {
+	w := tabwriter.NewWriter(dst, 0, 0, 3, ' ', 0)
 	defer w.Flush()
 
 	if headers {
 		fmt.Fprintf(w, "TIMESTAMP\t%s\n", stats.Timestamp.Format(time.RFC3339))
 		fmt.Fprintf(w, "TOTAL CONNECTIONS\t%d\n", stats.Total)
+		fmt.Fprintf(w, "RX / TX\t%s / %s\n", humanizeBytes(stats.RxBytes), humanizeBytes(stats.TxBytes))
+		if statsInterval > 0 {
+			mode := config.Get().Defaults.Units
+			fmt.Fprintf(w, "RX/S / TX/S\t%s / %s\n", units.FormatRate(stats.RxRate, mode), units.FormatRate(stats.TxRate, mode))
+		}
 		fmt.Fprintln(w)
 	}
 
@@ -289,11 +339,12 @@ func printStatsTable(stats *StatsData, headers bool) {
 func init() {
 	rootCmd.AddCommand(statsCmd)
 
+	// shared output flags
+	addOutputFlags(statsCmd, &statsOutputFormat, config.Get().Defaults.OutputFormat)
+
 	// stats-specific flags
-	statsCmd.Flags().StringVarP(&statsOutputFormat, "output", "o", "table", "Output format (table, json, csv)")
 	statsCmd.Flags().DurationVarP(&statsInterval, "interval", "i", 0, "Refresh interval (0 = one-shot)")
 	statsCmd.Flags().IntVarP(&statsCount, "count", "c", 0, "Number of iterations (0 = unlimited)")
-	statsCmd.Flags().BoolVar(&statsNoHeaders, "no-headers", false, "Omit headers for table/csv output")
 
 	// shared filter flags
 	addFilterFlags(statsCmd)
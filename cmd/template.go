@@ -0,0 +1,132 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/template"
+
+	"github.com/karol-broda/snitch/internal/config"
+	"github.com/karol-broda/snitch/internal/geoip"
+	"github.com/karol-broda/snitch/internal/resolver"
+	"github.com/karol-broda/snitch/internal/units"
+)
+
+// output formats that render a go template, given inline or from a file
+const (
+	templateFormat     = "template="
+	templateFileFormat = "template-file="
+)
+
+// templateFuncs are the helpers available to output templates
+var templateFuncs = template.FuncMap{
+	"host": func(addr string) string {
+		if addr == "" || addr == "*" {
+			return addr
+		}
+		return resolver.ResolveAddr(addr)
+	},
+	"service": func(port int, proto string) string {
+		if port == 0 {
+			return "0"
+		}
+		return resolver.ResolvePort(port, proto)
+	},
+	"bytes": func(n any) (string, error) {
+		v, err := toInt64(n)
+		if err != nil {
+			return "", err
+		}
+		return humanizeBytes(v), nil
+	},
+	"rate": func(n float64) string {
+		return units.FormatRate(n, config.Get().Defaults.Units)
+	},
+	"country": geoip.GetCountryCode,
+	"flag":    geoip.GetFlag,
+	"pad": func(width int, v any) string {
+		return fmt.Sprintf("%-*v", width, v)
+	},
+	"padleft": func(width int, v any) string {
+		return fmt.Sprintf("%*v", width, v)
+	},
+	"upper": strings.ToUpper,
+	"lower": strings.ToLower,
+	"join":  strings.Join,
+	"json": func(v any) (string, error) {
+		out, err := json.Marshal(v)
+		return string(out), err
+	},
+}
+
+// isTemplateFormat reports whether an -o value selects template output
+func isTemplateFormat(format string) bool {
+	return strings.HasPrefix(format, templateFormat) || strings.HasPrefix(format, templateFileFormat)
+}
+
+// parseTemplateFormat parses the template of -o template=TEXT or
+// -o template-file=PATH
+func parseTemplateFormat(format string) (*template.Template, error) {
+	var text string
+	switch {
+	case strings.HasPrefix(format, templateFormat):
+		text = strings.TrimPrefix(format, templateFormat)
+	case strings.HasPrefix(format, templateFileFormat):
+		path := strings.TrimPrefix(format, templateFileFormat)
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("cannot read template: %w", err)
+		}
+		text = string(data)
+	default:
+		return nil, fmt.Errorf("not a template format: %s", format)
+	}
+
+	tmpl, err := template.New("output").Funcs(templateFuncs).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid template: %w", err)
+	}
+	return tmpl, nil
+}
+
+// executeTemplate renders data, one item per line: a newline is added
+// unless the template ends with one
+func executeTemplate(w io.Writer, tmpl *template.Template, data any) error {
+	var out strings.Builder
+	if err := tmpl.Execute(&out, data); err != nil {
+		return err
+	}
+	text := out.String()
+	if !strings.HasSuffix(text, "\n") {
+		text += "\n"
+	}
+	_, err := io.WriteString(w, text)
+	return err
+}
+
+// humanizeBytes formats n in the units of the config file, e.g. 1536 as
+// 1.5KiB
+func humanizeBytes(n int64) string {
+	return units.FormatBytes(n, config.Get().Defaults.Units)
+}
+
+func toInt64(v any) (int64, error) {
+	switch n := v.(type) {
+	case int:
+		return int64(n), nil
+	case int32:
+		return int64(n), nil
+	case int64:
+		return n, nil
+	case uint32:
+		return int64(n), nil
+	case uint64:
+		return int64(n), nil
+	case float64:
+		return int64(n), nil
+	default:
+		return 0, fmt.Errorf("bytes: expected a number, got %T", v)
+	}
+}
@@ -0,0 +1,99 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/karol-broda/snitch/internal/collector"
+)
+
+func TestHumanizeBytes(t *testing.T) {
+	tests := []struct {
+		input    int64
+		expected string
+	}{
+		{0, "0B"},
+		{1023, "1023B"},
+		{1024, "1.0KiB"},
+		{1536, "1.5KiB"},
+		{5 * 1024 * 1024, "5.0MiB"},
+		{3 * 1024 * 1024 * 1024 * 1024, "3.0TiB"},
+	}
+
+	for _, tt := range tests {
+		if got := humanizeBytes(tt.input); got != tt.expected {
+			t.Errorf("humanizeBytes(%d) = %q, expected %q", tt.input, got, tt.expected)
+		}
+	}
+}
+
+func TestTemplateOutput(t *testing.T) {
+	conn := collector.Connection{
+		PID:     42,
+		Process: "nginx",
+		Proto:   "tcp",
+		Raddr:   "10.0.0.1",
+		Rport:   443,
+		RxBytes: 2048,
+	}
+	event := TraceEvent{Timestamp: time.Unix(0, 0), Event: "opened", Connection: conn}
+	stats := &StatsData{Total: 3, ByProto: map[string]int{"tcp": 2, "udp": 1}}
+
+	dir := t.TempDir()
+	file := filepath.Join(dir, "conn.tmpl")
+	if err := os.WriteFile(file, []byte("{{.Process}}[{{.PID}}]\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		format   string
+		data     any
+		expected string
+		wantErr  bool
+	}{
+		{"fields", "template={{.Process}} {{.Raddr}}:{{.Rport}}", conn, "nginx 10.0.0.1:443\n", false},
+		{"bytes", "template={{bytes .RxBytes}}", conn, "2.0KiB\n", false},
+		{"padding", "template={{pad 8 .Process}}|{{padleft 4 .PID}}", conn, "nginx   |  42\n", false},
+		{"upper", "template={{upper .Proto}}", conn, "TCP\n", false},
+		{"trace event", "template={{.Event}} {{.Connection.Process}}", event, "opened nginx\n", false},
+		{"stats", "template={{.Total}} tcp={{.ByProto.tcp}}", stats, "3 tcp=2\n", false},
+		{"file keeps its newline", "template-file=" + file, conn, "nginx[42]\n", false},
+		{"missing file", "template-file=" + filepath.Join(dir, "missing"), conn, "", true},
+		{"parse error", "template={{.Process", conn, "", true},
+		{"unknown field", "template={{.Bogus}}", conn, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !isTemplateFormat(tt.format) {
+				t.Fatalf("expected %q to be a template format", tt.format)
+			}
+
+			tmpl, err := parseTemplateFormat(tt.format)
+			var out strings.Builder
+			if err == nil {
+				err = executeTemplate(&out, tmpl, tt.data)
+			}
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("expected an error, got %q", out.String())
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if out.String() != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, out.String())
+			}
+		})
+	}
+
+	if isTemplateFormat("table") || isTemplateFormat("json") {
+		t.Error("expected table and json not to be template formats")
+	}
+}
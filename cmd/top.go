@@ -30,18 +30,31 @@ var topCmd = &cobra.Command{
 		opts := tui.Options{
 			Theme:    theme,
 			Interval: topInterval,
+			Units:    cfg.Defaults.Units,
 		}
 
 		// if any filter flag is set, use exclusive mode
-		if filterTCP || filterUDP || filterListen || filterEstab {
+		if filterTCP || filterUDP || filterUnix || filterListen || filterEstab {
 			opts.TCP = filterTCP
 			opts.UDP = filterUDP
+			opts.Unix = filterUnix
 			opts.Listening = filterListen
 			opts.Established = filterEstab
 			opts.Other = false
 			opts.FilterSet = true
 		}
 
+		excludes, err := BuildExcludes()
+		if err != nil {
+			log.Fatal(err)
+		}
+		opts.Exclude = excludes
+
+		for _, name := range cfg.QueryNames() {
+			q, _ := cfg.Query(name)
+			opts.Queries = append(opts.Queries, tui.SavedQuery{Name: name, Filter: q.Filter, Sort: q.Sort})
+		}
+
 		m := tui.New(opts)
 
 		p := tea.NewProgram(m, tea.WithAltScreen())
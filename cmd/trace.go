@@ -2,12 +2,14 @@ package cmd
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
+	"io"
 	"log"
 	"os"
 	"os/signal"
 	"github.com/karol-broda/snitch/internal/collector"
+	"github.com/karol-broda/snitch/internal/color"
+	"github.com/karol-broda/snitch/internal/config"
 	"github.com/karol-broda/snitch/internal/resolver"
 	"strings"
 	"syscall"
@@ -26,7 +28,6 @@ var (
 	traceInterval    time.Duration
 	traceCount       int
 	traceOutputFormat string
-	traceNumeric     bool
 	traceTimestamp   bool
 )
 
@@ -35,8 +36,12 @@ var traceCmd = &cobra.Command{
 	Short: "Print new/closed connections as they happen",
 	Long: `Print new/closed connections as they happen.
 	
-Filters are specified in key=value format. For example:
+Filters are key=value pairs, combined with and, or, not and parentheses.
+For example:
   snitch trace proto=tcp state=established
+  snitch trace 'proto=tcp and not lport in 22,53'
+
+Saved queries from the config file are run as @name, e.g. snitch trace @db.
 
 Available filters:
   proto, state, pid, proc, lport, rport, user, laddr, raddr, contains
@@ -52,6 +57,16 @@ func runTraceCommand(args []string) {
 		log.Fatalf("Error parsing filters: %v", err)
 	}
 
+	color.Init(colorMode)
+	renderer, err := rendererFor(traceOutputFormat, map[string]Renderer{
+		"table": RendererFunc(renderTraceHuman),
+		"wide":  RendererFunc(renderTraceHuman),
+		"human": RendererFunc(renderTraceHuman),
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
@@ -67,11 +82,10 @@ func runTraceCommand(args []string) {
 	currentConnections := make(map[string]collector.Connection)
 	
 	// Get initial snapshot
-	initialConnections, err := collector.GetConnections()
+	filteredInitial, err := FetchConnections(filters)
 	if err != nil {
 		log.Printf("Error getting initial connections: %v", err)
 	} else {
-		filteredInitial := collector.FilterConnections(initialConnections, filters)
 		for _, conn := range filteredInitial {
 			key := getConnectionKey(conn)
 			currentConnections[key] = conn
@@ -87,13 +101,12 @@ func runTraceCommand(args []string) {
 		case <-ctx.Done():
 			return
 		case <-ticker.C:
-			newConnections, err := collector.GetConnections()
+			filteredNew, err := FetchConnections(filters)
 			if err != nil {
 				log.Printf("Error getting connections: %v", err)
 				continue
 			}
 
-			filteredNew := collector.FilterConnections(newConnections, filters)
 			newConnectionsMap := make(map[string]collector.Connection)
 			
 			// Build map of new connections
@@ -110,7 +123,7 @@ func runTraceCommand(args []string) {
 						Event:      "opened",
 						Connection: conn,
 					}
-					printTraceEvent(event)
+					printTraceEvent(renderer, event, eventCount)
 					eventCount++
 				}
 			}
@@ -123,7 +136,7 @@ func runTraceCommand(args []string) {
 						Event:      "closed",
 						Connection: conn,
 					}
-					printTraceEvent(event)
+					printTraceEvent(renderer, event, eventCount)
 					eventCount++
 				}
 			}
@@ -141,28 +154,42 @@ func runTraceCommand(args []string) {
 func getConnectionKey(conn collector.Connection) string {
 	// Create a unique key for a connection based on protocol, addresses, ports, and PID
 	// This helps identify the same logical connection across snapshots
+	// Unix sockets mostly share the same unnamed address, so their inode is used instead
+	if conn.Proto == "unix" {
+		return fmt.Sprintf("%s|%d|%d", conn.Proto, conn.Inode, conn.PID)
+	}
 	return fmt.Sprintf("%s|%s:%d|%s:%d|%d", conn.Proto, conn.Laddr, conn.Lport, conn.Raddr, conn.Rport, conn.PID)
 }
 
-func printTraceEvent(event TraceEvent) {
-	switch traceOutputFormat {
-	case "json":
-		printTraceEventJSON(event)
-	default:
-		printTraceEventHuman(event)
+func printTraceEvent(r Renderer, event TraceEvent, eventCount int) {
+	row := getFieldMap(event.Connection)
+	row["event"] = event.Event
+	row["ts"] = event.Timestamp.Format("2006-01-02T15:04:05.000Z07:00")
+
+	out := Output{
+		Items:     []any{event},
+		Value:     event,
+		Fields:    selectFields(append([]string{"ts", "event"}, config.Get().Defaults.Fields...)),
+		Rows:      []map[string]string{row},
+		Headers:   !noHeaders,
+		Continued: eventCount > 0,
+		Stream:    true,
+	}
+	if err := r.Render(os.Stdout, out); err != nil {
+		log.Printf("Error rendering event: %v", err)
 	}
 }
 
-func printTraceEventJSON(event TraceEvent) {
-	jsonOutput, err := json.Marshal(event)
-	if err != nil {
-		log.Printf("Error marshaling JSON: %v", err)
-		return
+// renderTraceHuman prints events as one readable line each, the default
+// output of trace
+func renderTraceHuman(w io.Writer, out Output) error {
+	for _, item := range out.Items {
+		printTraceEventHuman(w, item.(TraceEvent))
 	}
-	fmt.Println(string(jsonOutput))
+	return nil
 }
 
-func printTraceEventHuman(event TraceEvent) {
+func printTraceEventHuman(w io.Writer, event TraceEvent) {
 	conn := event.Connection
 	
 	timestamp := ""
@@ -181,7 +208,7 @@ func printTraceEventHuman(event TraceEvent) {
 	rportStr := fmt.Sprintf("%d", conn.Rport)
 	
 	// Handle name resolution based on numeric flag
-	if !traceNumeric {
+	if !numeric {
 		if resolvedLaddr := resolver.ResolveAddr(conn.Laddr); resolvedLaddr != conn.Laddr {
 			laddr = resolvedLaddr
 		}
@@ -198,7 +225,9 @@ func printTraceEventHuman(event TraceEvent) {
 
 	// Format the connection string
 	var connStr string
-	if conn.Raddr != "" && conn.Raddr != "*" {
+	if conn.Proto == "unix" {
+		connStr = fmt.Sprintf("%s %s", conn.SockType, conn.Laddr)
+	} else if conn.Raddr != "" && conn.Raddr != "*" {
 		connStr = fmt.Sprintf("%s:%s->%s:%s", laddr, lportStr, raddr, rportStr)
 	} else {
 		connStr = fmt.Sprintf("%s:%s", laddr, lportStr)
@@ -215,17 +244,19 @@ func printTraceEventHuman(event TraceEvent) {
 		state = "UNKNOWN"
 	}
 
-	fmt.Printf("%s%s %s %s %s%s\n", timestamp, eventIcon, protocol, state, connStr, process)
+	fmt.Fprintf(w, "%s%s %s %s %s%s\n", timestamp, eventIcon, protocol, state, connStr, process)
 }
 
 func init() {
 	rootCmd.AddCommand(traceCmd)
 
+	// shared output flags, table prints the readable event lines
+	addOutputFlags(traceCmd, &traceOutputFormat, config.Get().Defaults.OutputFormat)
+
 	// trace-specific flags
 	traceCmd.Flags().DurationVarP(&traceInterval, "interval", "i", time.Second, "Polling interval (e.g., 500ms, 2s)")
 	traceCmd.Flags().IntVarP(&traceCount, "count", "c", 0, "Number of events to capture (0 = unlimited)")
-	traceCmd.Flags().StringVarP(&traceOutputFormat, "output", "o", "human", "Output format (human, json)")
-	traceCmd.Flags().BoolVarP(&traceNumeric, "numeric", "n", false, "Don't resolve hostnames")
+	traceCmd.Flags().BoolVarP(&numeric, "numeric", "n", config.Get().Defaults.Numeric, "Don't resolve hostnames")
 	traceCmd.Flags().BoolVar(&traceTimestamp, "ts", false, "Include timestamp in output")
 
 	// shared filter flags
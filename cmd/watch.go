@@ -2,7 +2,6 @@ package cmd
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"log"
 	"os"
@@ -10,12 +9,17 @@ import (
 	"syscall"
 	"time"
 
+	"github.com/karol-broda/snitch/internal/collector"
+	"github.com/karol-broda/snitch/internal/color"
+	"github.com/karol-broda/snitch/internal/config"
+
 	"github.com/spf13/cobra"
 )
 
 var (
-	watchInterval time.Duration
-	watchCount    int
+	watchInterval     time.Duration
+	watchCount        int
+	watchOutputFormat string
 )
 
 var watchCmd = &cobra.Command{
@@ -23,8 +27,12 @@ var watchCmd = &cobra.Command{
 	Short: "Stream connection events as json frames",
 	Long: `Stream connection events as json frames.
 	
-Filters are specified in key=value format. For example:
+Filters are key=value pairs, combined with and, or, not and parentheses.
+For example:
   snitch watch proto=tcp state=established
+  snitch watch 'proto=tcp and not lport in 22,53'
+
+Saved queries from the config file are run as @name, e.g. snitch watch @db.
 
 Available filters:
   proto, state, pid, proc, lport, rport, user, laddr, raddr, contains
@@ -50,9 +58,16 @@ func runWatchCommand(args []string) {
 		cancel()
 	}()
 
+	color.Init(colorMode)
+	renderer, err := rendererFor(watchOutputFormat, nil)
+	if err != nil {
+		log.Fatal(err)
+	}
+
 	ticker := time.NewTicker(watchInterval)
 	defer ticker.Stop()
 
+	rates := collector.NewRateTracker()
 	count := 0
 	for {
 		select {
@@ -64,6 +79,7 @@ func runWatchCommand(args []string) {
 				log.Printf("Error getting connections: %v", err)
 				continue
 			}
+			rates.Update(connections, time.Now())
 
 			frame := map[string]interface{}{
 				"timestamp":   time.Now().Format(time.RFC3339Nano),
@@ -71,13 +87,30 @@ func runWatchCommand(args []string) {
 				"count":       len(connections),
 			}
 
-			jsonOutput, err := json.Marshal(frame)
-			if err != nil {
-				log.Printf("Error marshaling JSON: %v", err)
-				continue
+			frameFields := selectFields(config.Get().Defaults.Fields)
+			rows := connectionRows(connections)
+			if watchOutputFormat == "wide" {
+				if fields == "" {
+					frameFields = wideFields(frameFields)
+				}
+				rows = wideRows(connections)
 			}
 
-			fmt.Println(string(jsonOutput))
+			// json and yaml write the whole frame, other formats its connections
+			out := Output{
+				Items:     toItems(connections),
+				Value:     frame,
+				Fields:    frameFields,
+				Rows:      rows,
+				Headers:   !noHeaders,
+				Continued: count > 0,
+				Stream:    true,
+				Summary:   fmt.Sprintf("%d connections", len(connections)),
+			}
+			if err := renderer.Render(os.Stdout, out); err != nil {
+				log.Printf("Error rendering frame: %v", err)
+				continue
+			}
 
 			count++
 			if watchCount > 0 && count >= watchCount {
@@ -90,6 +123,9 @@ func runWatchCommand(args []string) {
 func init() {
 	rootCmd.AddCommand(watchCmd)
 
+	// shared output flags. watch streams json frames unless told otherwise
+	addOutputFlags(watchCmd, &watchOutputFormat, "json")
+
 	// watch-specific flags
 	watchCmd.Flags().DurationVarP(&watchInterval, "interval", "i", time.Second, "Refresh interval (e.g., 500ms, 2s)")
 	watchCmd.Flags().IntVarP(&watchCount, "count", "c", 0, "Number of frames to emit (0 = unlimited)")
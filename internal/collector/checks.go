@@ -0,0 +1,19 @@
+package collector
+
+// CheckStatus is the outcome of an environment check
+type CheckStatus string
+
+const (
+	CheckPass CheckStatus = "pass"
+	CheckWarn CheckStatus = "warn"
+	CheckFail CheckStatus = "fail"
+)
+
+// Check is one thing `snitch doctor` verified about the host: what it
+// found and, when it is not a pass, how to fix it
+type Check struct {
+	Name   string      `json:"name"`
+	Status CheckStatus `json:"status"`
+	Detail string      `json:"detail"`
+	Hint   string      `json:"hint,omitempty"`
+}
@@ -0,0 +1,285 @@
+//go:build linux
+
+package collector
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"syscall"
+)
+
+// capabilityNames are the capabilities doctor reports, in display order
+var capabilityNames = []struct {
+	bit  uint
+	name string
+}{
+	{capSysPtrace, "CAP_SYS_PTRACE"},
+	{capDacReadSearch, "CAP_DAC_READ_SEARCH"},
+	{capDacOverride, "CAP_DAC_OVERRIDE"},
+	{capNetAdmin, "CAP_NET_ADMIN"},
+	{capSysAdmin, "CAP_SYS_ADMIN"},
+}
+
+// RunChecks reports what the linux collector can and cannot see on this
+// host, and why
+func RunChecks() []Check {
+	checks := []Check{
+		checkPrivileges(),
+		checkPtraceScope(),
+		checkHidepid(),
+		checkProcesses(),
+		checkNetNamespaces(),
+		checkSockDiag(),
+		checkUnixDiag(),
+		checkContainers(),
+	}
+	return checks
+}
+
+func checkPrivileges() Check {
+	caps, err := effectiveCapabilities()
+	if err != nil {
+		return Check{
+			Name:   "privileges",
+			Status: CheckWarn,
+			Detail: fmt.Sprintf("cannot read capabilities: %v", err),
+		}
+	}
+	return privilegesCheck(caps, os.Geteuid())
+}
+
+// privilegesCheck judges the capabilities caps of a process running as
+// euid. root only needs CAP_SYS_PTRACE to read the fds of processes with
+// other credentials; other users also need CAP_DAC_READ_SEARCH or
+// CAP_DAC_OVERRIDE to list them.
+func privilegesCheck(caps uint64, euid int) Check {
+	check := Check{Name: "privileges"}
+
+	var held []string
+	for _, c := range capabilityNames {
+		if caps&(1<<c.bit) != 0 {
+			held = append(held, c.name)
+		}
+	}
+	if len(held) == 0 {
+		held = []string{"no capabilities"}
+	}
+
+	user := fmt.Sprintf("uid %d", euid)
+	if euid == 0 {
+		user = "root"
+	}
+	check.Detail = user + ", " + strings.Join(held, ", ")
+
+	ptrace := caps&(1<<capSysPtrace) != 0
+	dac := caps&(1<<capDacReadSearch|1<<capDacOverride) != 0
+	switch {
+	case ptrace && (euid == 0 || dac):
+		check.Status = CheckPass
+	case euid == 0:
+		check.Status = CheckWarn
+		check.Hint = "root without CAP_SYS_PTRACE cannot see sockets of processes with other credentials; add it (e.g. docker run --cap-add SYS_PTRACE)"
+	case ptrace:
+		check.Status = CheckWarn
+		check.Hint = "fds of other users cannot be listed; grant CAP_DAC_READ_SEARCH as well"
+	case dac:
+		check.Status = CheckWarn
+		check.Hint = "fds of other users cannot be read; grant CAP_SYS_PTRACE as well"
+	default:
+		check.Status = CheckWarn
+		check.Hint = "only your own processes are attributed; run as root or grant CAP_SYS_PTRACE and CAP_DAC_READ_SEARCH"
+	}
+	return check
+}
+
+// ptraceScopes describes the values of kernel.yama.ptrace_scope
+var ptraceScopes = map[string]string{
+	"0": "classic ptrace permissions",
+	"1": "restricted to descendants",
+	"2": "admin-only attach",
+	"3": "no attach",
+}
+
+// checkPtraceScope reports kernel.yama.ptrace_scope for information. yama
+// only restricts attaching with ptrace, not the read access to
+// /proc/<pid>/fd snitch needs, so no value is a problem.
+func checkPtraceScope() Check {
+	check := Check{Name: "ptrace_scope", Status: CheckPass}
+
+	data, err := procfs.readFile("sys", "kernel", "yama", "ptrace_scope")
+	if err != nil {
+		check.Detail = "yama is not enabled"
+		return check
+	}
+
+	scope := strings.TrimSpace(string(data))
+	check.Detail = fmt.Sprintf("%s (%s, does not affect reading fds)", scope, ptraceScopes[scope])
+	return check
+}
+
+func checkHidepid() Check {
+	check := Check{Name: "hidepid"}
+
+	options, err := procMountOptions(procfs.root)
+	if err != nil {
+		check.Status = CheckWarn
+		check.Detail = err.Error()
+		return check
+	}
+
+	hidden := ""
+	for _, opt := range strings.Split(options, ",") {
+		switch {
+		case strings.HasPrefix(opt, "hidepid="):
+			if v := opt[len("hidepid="):]; v != "0" && v != "off" {
+				hidden = opt
+			}
+		case opt == "subset=pid":
+			hidden = opt
+		}
+	}
+
+	if hidden == "" {
+		check.Status = CheckPass
+		check.Detail = fmt.Sprintf("%s shows every process", procfs.root)
+		return check
+	}
+
+	check.Detail = fmt.Sprintf("%s is mounted with %s", procfs.root, hidden)
+	if caps, _ := effectiveCapabilities(); caps&(1<<capSysPtrace) != 0 {
+		check.Status = CheckPass
+		return check
+	}
+	check.Status = CheckWarn
+	check.Hint = fmt.Sprintf("processes of other users are invisible; run as root or join the gid= group of the %s mount", procfs.root)
+	return check
+}
+
+// procMountOptions returns the mount options of the proc filesystem mounted
+// at root, from /proc/self/mounts
+func procMountOptions(root string) (string, error) {
+	file, err := os.Open(procfs.path("self", "mounts"))
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		// device mountpoint fstype options dump pass
+		fields := strings.Fields(scanner.Text())
+		if len(fields) >= 4 && fields[1] == root && fields[2] == "proc" {
+			return fields[3], nil
+		}
+	}
+	return "", fmt.Errorf("%s is not a proc mount", root)
+}
+
+func checkProcesses() Check {
+	check := Check{Name: "processes"}
+
+	report := newDiagnostics()
+	if _, err := buildInodeToProcessMap(report); err != nil {
+		check.Status = CheckFail
+		check.Detail = fmt.Sprintf("cannot list %s: %v", procfs.root, err)
+		check.Hint = "point --proc-root at a mounted proc filesystem"
+		return check
+	}
+
+	check.Detail = fmt.Sprintf("%d of %d processes have readable fds", report.Processes-report.ProcessesDenied, report.Processes)
+	if report.ProcessesDenied == 0 {
+		check.Status = CheckPass
+		return check
+	}
+	check.Status = CheckWarn
+	check.Hint = "sockets of the other processes are listed without an owner"
+	if hint := report.privilegeHint(); hint != "" {
+		check.Hint += "; " + hint
+	}
+	return check
+}
+
+func checkNetNamespaces() Check {
+	check := Check{Name: "netns"}
+
+	namespaces, err := listNetNamespaces()
+	if err != nil {
+		check.Status = CheckFail
+		check.Detail = fmt.Sprintf("cannot read network namespaces: %v", err)
+		check.Hint = "only sockets of the namespace snitch runs in are listed"
+		return check
+	}
+
+	named := 0
+	for _, ns := range namespaces {
+		if ns.name != "" {
+			named++
+		}
+	}
+	check.Status = CheckPass
+	check.Detail = fmt.Sprintf("%d namespaces visible, %d named", len(namespaces), named)
+
+	if _, err := os.ReadDir(netnsRunDir); err != nil && !os.IsNotExist(err) {
+		check.Status = CheckWarn
+		check.Hint = fmt.Sprintf("cannot read %s for `ip netns` names: %v", netnsRunDir, err)
+	}
+	return check
+}
+
+func checkSockDiag() Check {
+	check := Check{Name: "sock_diag"}
+
+	fd, err := openSockDiag()
+	if err == nil {
+		_, err = dumpDiagTable(fd, 1, diagTables[0], nil)
+		syscall.Close(fd)
+	}
+	if err != nil {
+		check.Status = CheckWarn
+		check.Detail = fmt.Sprintf("unavailable: %v", err)
+		check.Hint = "the auto backend falls back to /proc without tcp_info metrics; load inet_diag, tcp_diag and udp_diag"
+		return check
+	}
+
+	check.Status = CheckPass
+	check.Detail = "inet sockets are dumped over netlink"
+	return check
+}
+
+func checkUnixDiag() Check {
+	check := Check{Name: "unix_diag"}
+
+	if _, err := dumpUnixPeers(); err != nil {
+		check.Status = CheckWarn
+		check.Detail = fmt.Sprintf("unavailable: %v", err)
+		check.Hint = "unix sockets are listed without their peer; load unix_diag"
+		return check
+	}
+
+	check.Status = CheckPass
+	check.Detail = "unix socket peers are resolved"
+	return check
+}
+
+func checkContainers() Check {
+	check := Check{Name: "containers"}
+
+	if _, err := procfs.readFile("self", "cgroup"); err != nil {
+		check.Status = CheckFail
+		check.Detail = fmt.Sprintf("cannot read cgroups: %v", err)
+		check.Hint = "sockets are not attributed to containers or pods"
+		return check
+	}
+
+	containers, pods, unnamed := processes.containerCounts()
+	check.Detail = fmt.Sprintf("cgroups readable, %d containers and %d pods found", containers, pods)
+	if unnamed > 0 {
+		check.Status = CheckWarn
+		check.Hint = fmt.Sprintf("%d pods have no name because the kubelet's /var/log/pods is not readable through %s; run as root on the node", unnamed, procfs.pidPath(1, "root"))
+		return check
+	}
+	check.Status = CheckPass
+	return check
+}
@@ -0,0 +1,117 @@
+//go:build linux
+
+package collector
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// fakeSelfStatus points selfStatus at a status file with the given CapEff
+// for the rest of the test
+func fakeSelfStatus(t *testing.T, capEff string) {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "status")
+	if err := os.WriteFile(path, []byte("Name:\tsnitch\nCapEff:\t"+capEff+"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	previous := selfStatus
+	selfStatus = path
+	t.Cleanup(func() { selfStatus = previous })
+}
+
+func TestCheckHidepidAndPtraceScope(t *testing.T) {
+	testCases := []struct {
+		name         string
+		mountOptions string
+		ptraceScope  string
+		hidepid      CheckStatus
+		ptrace       CheckStatus
+	}{
+		{"open", "rw,nosuid,nodev,noexec,relatime", "1\n", CheckPass, CheckPass},
+		{"hidepid off", "rw,relatime,hidepid=0", "0\n", CheckPass, CheckPass},
+		{"hidepid invisible", "rw,relatime,hidepid=invisible,gid=27", "2\n", CheckWarn, CheckPass},
+		{"subset", "rw,relatime,subset=pid", "3\n", CheckWarn, CheckPass},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			root := t.TempDir()
+			writeProcTree(t, root, map[string]string{
+				"stat":                         "cpu  0 0 0 0\nbtime 1700000000\n",
+				"self/mounts":                  "sysfs /sys sysfs rw 0 0\nproc " + root + " proc " + tc.mountOptions + " 0 0\n",
+				"sys/kernel/yama/ptrace_scope": tc.ptraceScope,
+			})
+			fakeSelfStatus(t, "0000000000000000")
+
+			if err := SetProcRoot(root); err != nil {
+				t.Fatal(err)
+			}
+			t.Cleanup(func() { SetProcRoot(DefaultProcRoot) })
+
+			if check := checkHidepid(); check.Status != tc.hidepid {
+				t.Errorf("hidepid: expected %s, got %s (%s)", tc.hidepid, check.Status, check.Detail)
+			}
+			if check := checkPtraceScope(); check.Status != tc.ptrace || check.Hint != "" {
+				t.Errorf("ptrace_scope: expected %s without a hint, got %s (%s) %q", tc.ptrace, check.Status, check.Detail, check.Hint)
+			}
+		})
+	}
+}
+
+func TestCheckPrivilegesWithoutCapabilities(t *testing.T) {
+	root := t.TempDir()
+	writeProcTree(t, root, map[string]string{
+		"stat": "cpu  0 0 0 0\nbtime 1700000000\n",
+	})
+	fakeSelfStatus(t, "0000000000080004")
+
+	if err := SetProcRoot(root); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { SetProcRoot(DefaultProcRoot) })
+
+	check := checkPrivileges()
+	if check.Status != CheckPass {
+		t.Errorf("expected CAP_SYS_PTRACE and CAP_DAC_READ_SEARCH to pass, got %s (%s)", check.Status, check.Detail)
+	}
+}
+
+func TestPrivilegesCheck(t *testing.T) {
+	const (
+		ptrace     = 1 << capSysPtrace
+		readSearch = 1 << capDacReadSearch
+		override   = 1 << capDacOverride
+	)
+
+	testCases := []struct {
+		name   string
+		caps   uint64
+		euid   int
+		status CheckStatus
+		hint   string
+	}{
+		{"root with ptrace", ptrace, 0, CheckPass, ""},
+		{"root without ptrace", readSearch | override, 0, CheckWarn, "CAP_SYS_PTRACE"},
+		{"user with ptrace and read search", ptrace | readSearch, 1000, CheckPass, ""},
+		{"user with ptrace and dac override", ptrace | override, 1000, CheckPass, ""},
+		{"user with ptrace only", ptrace, 1000, CheckWarn, "CAP_DAC_READ_SEARCH"},
+		{"user with read search only", readSearch, 1000, CheckWarn, "CAP_SYS_PTRACE"},
+		{"user without capabilities", 0, 1000, CheckWarn, "CAP_SYS_PTRACE and CAP_DAC_READ_SEARCH"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			check := privilegesCheck(tc.caps, tc.euid)
+			if check.Status != tc.status {
+				t.Errorf("expected %s, got %s (%s)", tc.status, check.Status, check.Detail)
+			}
+			if (tc.hint == "" && check.Hint != "") || !strings.Contains(check.Hint, tc.hint) {
+				t.Errorf("expected hint with %q, got %q", tc.hint, check.Hint)
+			}
+		})
+	}
+}
@@ -1,7 +1,6 @@
 package collector
 
 import (
-	"net"
 	"strings"
 )
 
@@ -10,6 +9,23 @@ type Collector interface {
 	GetConnections() ([]Connection, error)
 }
 
+// UnixSocketCollector is implemented by collectors that can also list unix
+// domain sockets. they are kept apart from GetConnections so commands only
+// pay for them when asked to show them.
+type UnixSocketCollector interface {
+	GetUnixSockets() ([]Connection, error)
+}
+
+// Collector backends selectable with --collector or the collector config key
+const (
+	BackendAuto    = "auto"
+	BackendProc    = "proc"
+	BackendNetlink = "netlink"
+)
+
+// DefaultProcRoot is where the proc filesystem is normally mounted
+const DefaultProcRoot = "/proc"
+
 // Global collector instance (can be overridden for testing)
 var globalCollector Collector = &DefaultCollector{}
 
@@ -28,6 +44,31 @@ func GetConnections() ([]Connection, error) {
 	return globalCollector.GetConnections()
 }
 
+// GetUnixSockets fetches unix domain sockets using the global collector.
+// collectors without unix socket support report none.
+func GetUnixSockets() ([]Connection, error) {
+	uc, ok := globalCollector.(UnixSocketCollector)
+	if !ok {
+		return nil, nil
+	}
+	return uc.GetUnixSockets()
+}
+
+// GetAllConnections returns both network and unix domain sockets
+func GetAllConnections() ([]Connection, error) {
+	connections, err := GetConnections()
+	if err != nil {
+		return nil, err
+	}
+
+	unixConns, err := GetUnixSockets()
+	if err != nil {
+		return nil, err
+	}
+
+	return append(connections, unixConns...), nil
+}
+
 func FilterConnections(conns []Connection, filters FilterOptions) []Connection {
 	if filters.IsEmpty() {
 		return conns
@@ -42,25 +83,6 @@ func FilterConnections(conns []Connection, filters FilterOptions) []Connection {
 	return filtered
 }
 
-func guessNetworkInterface(addr string) string {
-	if addr == "127.0.0.1" || addr == "::1" {
-		return "lo"
-	}
-
-	ip := net.ParseIP(addr)
-	if ip == nil {
-		return ""
-	}
-
-	if ip.IsLoopback() {
-		return "lo"
-	}
-
-	// default interface name varies by OS but we return a generic value
-	// actual interface detection would require routing table analysis
-	return ""
-}
-
 func simplifyIPv6(addr string) string {
 	parts := strings.Split(addr, ":")
 	for i, part := range parts {
@@ -104,7 +104,9 @@ import "C"
 import (
 	"fmt"
 	"net"
+	"os"
 	"strconv"
+	"strings"
 	"time"
 	"unsafe"
 )
@@ -112,6 +114,25 @@ import (
 // DefaultCollector implements the Collector interface using libproc on macOS
 type DefaultCollector struct{}
 
+// NewCollector returns the collector for the named backend. macOS only has
+// the libproc backend, so "auto" is the only accepted value.
+func NewCollector(backend string) (Collector, error) {
+	switch strings.ToLower(backend) {
+	case "", BackendAuto:
+		return &DefaultCollector{}, nil
+	default:
+		return nil, fmt.Errorf("collector backend %q is not supported on darwin", backend)
+	}
+}
+
+// SetProcRoot only accepts the default, as macOS has no proc filesystem
+func SetProcRoot(root string) error {
+	if root == "" || root == DefaultProcRoot {
+		return nil
+	}
+	return fmt.Errorf("proc root %s is not supported on darwin", root)
+}
+
 // GetConnections fetches all network connections using libproc
 func (dc *DefaultCollector) GetConnections() ([]Connection, error) {
 	pids, err := listAllPids()
@@ -129,6 +150,8 @@ func (dc *DefaultCollector) GetConnections() ([]Connection, error) {
 		connections = append(connections, procConns...)
 	}
 
+	newSystemInterfaceTable().assign(connections)
+
 	return connections, nil
 }
 
@@ -278,7 +301,9 @@ func getSocketInfo(pid, fd int, procName string, uid int, user string) (Connecti
 		Process:   procName,
 		UID:       uid,
 		User:      user,
-		Interface: guessNetworkInterface(laddr),
+	}
+	if uid >= 0 {
+		conn.UIDSource = UIDSourceProcess
 	}
 
 	return conn, true
@@ -336,3 +361,17 @@ func tcpStateToString(state int) string {
 	}
 	return ""
 }
+
+// RunChecks reports what the libproc collector can see. without root only
+// the user's own processes are attributed.
+func RunChecks() []Check {
+	if os.Geteuid() == 0 {
+		return []Check{{Name: "privileges", Status: CheckPass, Detail: "root"}}
+	}
+	return []Check{{
+		Name:   "privileges",
+		Status: CheckWarn,
+		Detail: fmt.Sprintf("uid %d", os.Geteuid()),
+		Hint:   "only your own processes are attributed; run with sudo",
+	}}
+}
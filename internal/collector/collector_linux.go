@@ -11,174 +11,210 @@ import (
 	"path/filepath"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 )
 
 // DefaultCollector implements the Collector interface using /proc filesystem
-type DefaultCollector struct{}
+type DefaultCollector struct {
+	diag lastDiagnostics
+}
 
-// GetConnections fetches all network connections by parsing /proc files
-func (dc *DefaultCollector) GetConnections() ([]Connection, error) {
-	inodeMap, err := buildInodeToProcessMap()
-	if err != nil {
-		return nil, fmt.Errorf("failed to build inode map: %w", err)
+// NewCollector returns the collector for the named backend. "auto" prefers
+// sock_diag and falls back to parsing /proc when it is unavailable.
+func NewCollector(backend string) (Collector, error) {
+	switch strings.ToLower(backend) {
+	case "", BackendAuto:
+		return &NetlinkCollector{Fallback: &DefaultCollector{}}, nil
+	case BackendNetlink:
+		return &NetlinkCollector{}, nil
+	case BackendProc:
+		return &DefaultCollector{}, nil
+	default:
+		return nil, fmt.Errorf("unknown collector backend: %s (expected auto, netlink, proc)", backend)
 	}
+}
 
-	var connections []Connection
-
-	tcpConns, err := parseProcNet("/proc/net/tcp", "tcp", 4, inodeMap)
-	if err == nil {
-		connections = append(connections, tcpConns...)
-	}
+// GetConnections fetches all network connections by parsing /proc files.
+// every network namespace with a visible process is read through that
+// process's /proc/<pid>/net view.
+func (dc *DefaultCollector) GetConnections() ([]Connection, error) {
+	report := newDiagnostics()
+	defer func() { dc.diag.set(*report) }()
 
-	tcpConns6, err := parseProcNet("/proc/net/tcp6", "tcp6", 6, inodeMap)
-	if err == nil {
-		connections = append(connections, tcpConns6...)
+	inodeMap, err := buildInodeToProcessMap(report)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build inode map: %w", err)
 	}
 
-	udpConns, err := parseProcNet("/proc/net/udp", "udp", 4, inodeMap)
-	if err == nil {
-		connections = append(connections, udpConns...)
+	namespaces, err := listNetNamespaces()
+	if err != nil {
+		netDir := procfs.path("net")
+		ifaces := loadInterfaceTable(netDir, true)
+		connections := parseProcNetTables(netDir, inodeMap, ifaces, report)
+		ifaces.assign(connections)
+		processes.resolve(connections, inodeMap)
+		return connections, nil
 	}
 
-	udpConns6, err := parseProcNet("/proc/net/udp6", "udp6", 6, inodeMap)
-	if err == nil {
-		connections = append(connections, udpConns6...)
+	var connections []Connection
+	for _, ns := range namespaces {
+		netDir := procfs.netDir(ns.pid)
+		ifaces := loadInterfaceTable(netDir, ns.self)
+		conns := parseProcNetTables(netDir, inodeMap, ifaces, report)
+		ns.tag(conns)
+		ifaces.assign(conns)
+		connections = append(connections, conns...)
 	}
+	processes.resolve(connections, inodeMap)
 
 	return connections, nil
 }
 
-// GetAllConnections returns both network and Unix domain socket connections
-func GetAllConnections() ([]Connection, error) {
-	networkConns, err := GetConnections()
-	if err != nil {
-		return nil, err
-	}
+// Diagnostics reports what the last collection could not see
+func (dc *DefaultCollector) Diagnostics() Diagnostics {
+	return dc.diag.get()
+}
 
-	unixConns, err := GetUnixSockets()
-	if err == nil {
-		networkConns = append(networkConns, unixConns...)
-	}
+// procNetTable is a /proc/net socket table in the tcp/udp line format
+type procNetTable struct {
+	file      string
+	proto     string
+	ipVersion int
+}
 
-	return networkConns, nil
+// procNetTables lists the socket tables sock_diag also covers
+var procNetTables = []procNetTable{
+	{"tcp", "tcp", 4},
+	{"tcp6", "tcp6", 6},
+	{"udp", "udp", 4},
+	{"udp6", "udp6", 6},
 }
 
-type processInfo struct {
-	pid     int
-	command string
-	uid     int
-	user    string
+// procExtraTables share the tcp/udp line format but are always read from
+// /proc, whichever backend is in use. raw sockets report the ip protocol
+// number as their local port.
+var procExtraTables = []procNetTable{
+	{"raw", "raw", 4},
+	{"raw6", "raw6", 6},
+	{"udplite", "udplite", 4},
+	{"udplite6", "udplite6", 6},
+	{"icmp", "icmp", 4},
+	{"icmp6", "icmp6", 6},
 }
 
-func buildInodeToProcessMap() (map[int64]*processInfo, error) {
-	inodeMap := make(map[int64]*processInfo)
+// parseProcNetTables parses every socket table in dir. unreadable tables are
+// skipped and recorded in report.
+func parseProcNetTables(dir string, inodeMap map[int64][]socketOwner, ifaces *interfaceTable, report *Diagnostics) []Connection {
+	connections := parseTables(dir, procNetTables, inodeMap, report)
+	return append(connections, parseProcExtraTables(dir, inodeMap, ifaces, report)...)
+}
 
-	procDir, err := os.Open("/proc")
-	if err != nil {
-		return nil, err
-	}
-	defer procDir.Close()
+// parseProcExtraTables parses the socket tables sock_diag is not used for:
+// raw, udplite, icmp, packet and sctp. tables for protocols whose module is
+// not loaded simply do not exist and are skipped.
+func parseProcExtraTables(dir string, inodeMap map[int64][]socketOwner, ifaces *interfaceTable, report *Diagnostics) []Connection {
+	connections := parseTables(dir, procExtraTables, inodeMap, report)
 
-	entries, err := procDir.Readdir(-1)
-	if err != nil {
-		return nil, err
-	}
+	packetPath := filepath.Join(dir, "packet")
+	conns, err := parseProcPacket(packetPath, inodeMap, ifaces)
+	report.tableFailed(packetPath, err)
+	connections = append(connections, conns...)
 
-	for _, entry := range entries {
-		if !entry.IsDir() {
-			continue
-		}
+	epsPath := filepath.Join(dir, "sctp", "eps")
+	conns, err = parseProcSCTPEndpoints(epsPath, inodeMap)
+	report.tableFailed(epsPath, err)
+	connections = append(connections, conns...)
 
-		pidStr := entry.Name()
-		pid, err := strconv.Atoi(pidStr)
-		if err != nil {
-			continue
-		}
+	assocsPath := filepath.Join(dir, "sctp", "assocs")
+	conns, err = parseProcSCTPAssocs(assocsPath, inodeMap)
+	report.tableFailed(assocsPath, err)
+	connections = append(connections, conns...)
 
-		procInfo, err := getProcessInfo(pid)
-		if err != nil {
-			continue
-		}
+	return connections
+}
 
-		fdDir := filepath.Join("/proc", pidStr, "fd")
-		fdEntries, err := os.ReadDir(fdDir)
+func parseTables(dir string, tables []procNetTable, inodeMap map[int64][]socketOwner, report *Diagnostics) []Connection {
+	var connections []Connection
+	for _, table := range tables {
+		path := filepath.Join(dir, table.file)
+		conns, err := parseProcNet(path, table.proto, table.ipVersion, inodeMap)
 		if err != nil {
+			report.tableFailed(path, err)
 			continue
 		}
-
-		for _, fdEntry := range fdEntries {
-			fdPath := filepath.Join(fdDir, fdEntry.Name())
-			link, err := os.Readlink(fdPath)
-			if err != nil {
-				continue
-			}
-
-			if strings.HasPrefix(link, "socket:[") && strings.HasSuffix(link, "]") {
-				inodeStr := link[8 : len(link)-1]
-				inode, err := strconv.ParseInt(inodeStr, 10, 64)
-				if err != nil {
-					continue
-				}
-				inodeMap[inode] = procInfo
-			}
-		}
+		connections = append(connections, conns...)
 	}
+	return connections
+}
 
-	return inodeMap, nil
+type processInfo struct {
+	pid       int
+	command   string
+	uid       int
+	user      string
+	container containerInfo
+
+	cmdline   string
+	exe       string
+	cwd       string
+	ppid      int
+	tgid      int
+	startTime time.Time
 }
 
 func getProcessInfo(pid int) (*processInfo, error) {
 	info := &processInfo{pid: pid}
-
-	commPath := filepath.Join("/proc", strconv.Itoa(pid), "comm")
-	commData, err := os.ReadFile(commPath)
+	commData, err := procfs.readPidFile(pid, "comm")
 	if err == nil && len(commData) > 0 {
 		info.command = strings.TrimSpace(string(commData))
 	}
 
-	if info.command == "" {
-		cmdlinePath := filepath.Join("/proc", strconv.Itoa(pid), "cmdline")
-		cmdlineData, err := os.ReadFile(cmdlinePath)
-		if err != nil {
-			return nil, err
-		}
-
-		if len(cmdlineData) > 0 {
-			parts := bytes.Split(cmdlineData, []byte{0})
-			if len(parts) > 0 && len(parts[0]) > 0 {
-				fullPath := string(parts[0])
-				baseName := filepath.Base(fullPath)
-				if strings.Contains(baseName, " ") {
-					baseName = strings.Fields(baseName)[0]
-				}
-				info.command = baseName
+	cmdlineData, err := procfs.readPidFile(pid, "cmdline")
+	if err != nil && info.command == "" {
+		return nil, err
+	}
+	info.cmdline = formatCmdline(cmdlineData)
+
+	if info.command == "" && len(cmdlineData) > 0 {
+		parts := bytes.Split(cmdlineData, []byte{0})
+		if len(parts) > 0 && len(parts[0]) > 0 {
+			fullPath := string(parts[0])
+			baseName := filepath.Base(fullPath)
+			if strings.Contains(baseName, " ") {
+				baseName = strings.Fields(baseName)[0]
 			}
+			info.command = baseName
 		}
 	}
 
-	statusPath := filepath.Join("/proc", strconv.Itoa(pid), "status")
-	statusFile, err := os.Open(statusPath)
+	// exe and cwd are only readable for our own processes unless running as root
+	info.exe, _ = procfs.readPidLink(pid, "exe")
+	info.cwd, _ = procfs.readPidLink(pid, "cwd")
+	info.startTime = readProcessStartTime(pid)
+	info.container = getContainerInfo(pid)
+
+	statusFile, err := os.Open(procfs.pidPath(pid, "status"))
 	if err != nil {
 		return info, nil
 	}
 	defer statusFile.Close()
 
+	// Tgid and PPid come before Uid in /proc/<pid>/status
 	scanner := bufio.NewScanner(statusFile)
 	for scanner.Scan() {
 		line := scanner.Text()
-		if strings.HasPrefix(line, "Uid:") {
+		if strings.HasPrefix(line, "Tgid:") {
+			info.tgid, _ = strconv.Atoi(strings.TrimSpace(line[5:]))
+		} else if strings.HasPrefix(line, "PPid:") {
+			info.ppid, _ = strconv.Atoi(strings.TrimSpace(line[5:]))
+		} else if strings.HasPrefix(line, "Uid:") {
 			fields := strings.Fields(line)
 			if len(fields) >= 2 {
 				uid, err := strconv.Atoi(fields[1])
 				if err == nil {
 					info.uid = uid
-					u, err := user.LookupId(strconv.Itoa(uid))
-					if err == nil {
-						info.user = u.Username
-					} else {
-						info.user = strconv.Itoa(uid)
-					}
+					info.user = lookupUsername(uid)
 				}
 			}
 			break
@@ -188,7 +224,32 @@ func getProcessInfo(pid int) (*processInfo, error) {
 	return info, nil
 }
 
-func parseProcNet(path, proto string, ipVersion int, inodeMap map[int64]*processInfo) ([]Connection, error) {
+// usernames caches uid lookups, which read /etc/passwd or ask nss every time
+var usernames sync.Map
+
+// lookupUsername returns the name of uid, or the uid itself when it has none
+func lookupUsername(uid int) string {
+	if name, ok := usernames.Load(uid); ok {
+		return name.(string)
+	}
+
+	name := strconv.Itoa(uid)
+	if u, err := user.LookupId(name); err == nil {
+		name = u.Username
+	}
+	usernames.Store(uid, name)
+	return name
+}
+
+// setSocketUID fills the user of conn from the uid column of a kernel socket
+// table. attachProcess replaces it when the owning process can be read.
+func setSocketUID(conn *Connection, uid int) {
+	conn.UID = uid
+	conn.User = lookupUsername(uid)
+	conn.UIDSource = UIDSourceSocket
+}
+
+func parseProcNet(path, proto string, ipVersion int, inodeMap map[int64][]socketOwner) ([]Connection, error) {
 	file, err := os.Open(path)
 	if err != nil {
 		return nil, err
@@ -222,15 +283,19 @@ func parseProcNet(path, proto string, ipVersion int, inodeMap map[int64]*process
 		}
 
 		stateHex := fields[3]
-		state := parseState(stateHex, proto)
+		state := refineUDPState(parseState(stateHex, proto), proto, remoteAddr, remotePort)
 
+		uid, uidErr := strconv.Atoi(fields[7])
 		inode, _ := strconv.ParseInt(fields[9], 10, 64)
 
-		// refine udp state: if unconnected and remote is wildcard, it's listening
-		if strings.HasPrefix(proto, "udp") && state == "UNCONNECTED" {
-			if remoteAddr == "*" && remotePort == 0 {
-				state = "LISTEN"
-			}
+		sendQ, recvQ := parseQueues(fields[4])
+		timer, _ := strconv.ParseInt(strings.SplitN(fields[5], ":", 2)[0], 16, 32)
+		retrans, _ := strconv.ParseInt(fields[6], 16, 32)
+
+		// tcp lines carry rto, ato, quick/pingpong, cwnd and ssthresh after the pointer
+		cwnd := 0
+		if strings.HasPrefix(proto, "tcp") && len(fields) > 15 {
+			cwnd, _ = strconv.Atoi(fields[15])
 		}
 
 		conn := Connection{
@@ -243,16 +308,18 @@ func parseProcNet(path, proto string, ipVersion int, inodeMap map[int64]*process
 			Raddr:     remoteAddr,
 			Rport:     remotePort,
 			Inode:     inode,
-		}
 
-		if procInfo, exists := inodeMap[inode]; exists {
-			conn.PID = procInfo.pid
-			conn.Process = procInfo.command
-			conn.UID = procInfo.uid
-			conn.User = procInfo.user
+			SendQ:       sendQ,
+			RecvQ:       recvQ,
+			Retransmits: int(retrans),
+			Cwnd:        cwnd,
+			Timer:       timerName(int(timer)),
 		}
 
-		conn.Interface = guessNetworkInterface(localAddr)
+		if uidErr == nil {
+			setSocketUID(&conn, uid)
+		}
+		attachProcess(&conn, inodeMap)
 
 		connections = append(connections, conn)
 	}
@@ -260,12 +327,90 @@ func parseProcNet(path, proto string, ipVersion int, inodeMap map[int64]*process
 	return connections, scanner.Err()
 }
 
+// parseQueues splits the "tx_queue:rx_queue" column of /proc/net/* into
+// send and receive queue sizes
+func parseQueues(col string) (int, int) {
+	parts := strings.SplitN(col, ":", 2)
+	if len(parts) != 2 {
+		return 0, 0
+	}
+	tx, _ := strconv.ParseInt(parts[0], 16, 64)
+	rx, _ := strconv.ParseInt(parts[1], 16, 64)
+	return int(tx), int(rx)
+}
+
+// timerName maps the kernel's pending timer code (the "tr" column of
+// /proc/net/tcp and idiag_timer in sock_diag) to the names ss uses
+func timerName(timer int) string {
+	switch timer {
+	case 0:
+		return "off"
+	case 1:
+		return "on"
+	case 2:
+		return "keepalive"
+	case 3:
+		return "timewait"
+	case 4:
+		return "persist"
+	default:
+		return ""
+	}
+}
+
+// socketOwner is a process holding a socket inode under one or more fds
+type socketOwner struct {
+	info *processInfo
+	fds  []int
+}
+
+// attachProcess fills the owning process fields of conn from the inode map.
+// the process fields describe the lowest pid, which for sockets inherited
+// across fork is usually the parent that created them. the user is kept
+// from the socket table when the process status is unreadable.
+func attachProcess(conn *Connection, inodeMap map[int64][]socketOwner) {
+	owners := inodeMap[conn.Inode]
+	if len(owners) == 0 {
+		return
+	}
+
+	conn.Owners = make([]Owner, len(owners))
+	for i, owner := range owners {
+		conn.Owners[i] = Owner{PID: owner.info.pid, Process: owner.info.command, FDs: owner.fds}
+	}
+
+	procInfo := owners[0].info
+	conn.PID = procInfo.pid
+	conn.Process = procInfo.command
+	if procInfo.user != "" {
+		conn.UID = procInfo.uid
+		conn.User = procInfo.user
+		conn.UIDSource = UIDSourceProcess
+	}
+	conn.ContainerRuntime = procInfo.container.runtime
+	conn.ContainerID = procInfo.container.id
+	conn.PodUID = procInfo.container.podUID
+	conn.PodName = procInfo.container.podName
+	conn.Cmdline = procInfo.cmdline
+	conn.Exe = procInfo.exe
+	conn.Cwd = procInfo.cwd
+	conn.PPID = procInfo.ppid
+	conn.TGID = procInfo.tgid
+	conn.StartTime = procInfo.startTime
+}
+
 func parseState(hexState, proto string) string {
 	state, err := strconv.ParseInt(hexState, 16, 32)
 	if err != nil {
 		return ""
 	}
 
+	return stateName(state, proto)
+}
+
+// stateName maps a kernel socket state number to its name. /proc/net/* and
+// sock_diag both report the same tcp_states.h values.
+func stateName(state int64, proto string) string {
 	tcpStates := map[int64]string{
 		0x01: "ESTABLISHED",
 		0x02: "SYN_SENT",
@@ -303,6 +448,16 @@ func parseState(hexState, proto string) string {
 	return ""
 }
 
+// refineUDPState reports unconnected udp sockets with a wildcard remote as listening
+func refineUDPState(state, proto, raddr string, rport int) string {
+	if strings.HasPrefix(proto, "udp") && state == "UNCONNECTED" {
+		if raddr == "*" && rport == 0 {
+			return "LISTEN"
+		}
+	}
+	return state
+}
+
 func parseHexAddr(hexAddr string) (string, int, error) {
 	parts := strings.Split(hexAddr, ":")
 	if len(parts) != 2 {
@@ -354,45 +509,3 @@ func parseHexAddr(hexAddr string) (string, int, error) {
 
 	return "", 0, fmt.Errorf("unsupported address format")
 }
-
-func GetUnixSockets() ([]Connection, error) {
-	connections := []Connection{}
-
-	file, err := os.Open("/proc/net/unix")
-	if err != nil {
-		return connections, nil
-	}
-	defer file.Close()
-
-	scanner := bufio.NewScanner(file)
-	scanner.Scan()
-
-	for scanner.Scan() {
-		line := strings.TrimSpace(scanner.Text())
-		fields := strings.Fields(line)
-		if len(fields) < 7 {
-			continue
-		}
-
-		inode, _ := strconv.ParseInt(fields[6], 10, 64)
-		path := ""
-		if len(fields) > 7 {
-			path = fields[7]
-		}
-
-		conn := Connection{
-			TS:        time.Now(),
-			Proto:     "unix",
-			Laddr:     path,
-			Raddr:     "",
-			State:     "CONNECTED",
-			Inode:     inode,
-			Interface: "unix",
-		}
-
-		connections = append(connections, conn)
-	}
-
-	return connections, nil
-}
-
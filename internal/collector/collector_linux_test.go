@@ -0,0 +1,98 @@
+//go:build linux
+
+package collector
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeProcNet(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "tcp")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write fixture: %v", err)
+	}
+	return path
+}
+
+func TestParseProcNetTCPMetrics(t *testing.T) {
+	path := writeProcNet(t, `  sl  local_address rem_address   st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode
+   0: 0100007F:1F90 0100007F:C350 01 00001000:00000020 01:00000014 00000003  1000        0 4242 1 0000000000000000 20 4 30 7 -1
+   1: 00000000:0050 00000000:0000 0A 00000000:00000005 00:00000000 00000000     0        0 4243 1 0000000000000000 100 0 0 10 0
+`)
+
+	conns, err := parseProcNet(path, "tcp", 4, map[int64][]socketOwner{})
+	if err != nil {
+		t.Fatalf("parseProcNet failed: %v", err)
+	}
+	if len(conns) != 2 {
+		t.Fatalf("expected 2 connections, got %d", len(conns))
+	}
+
+	estab := conns[0]
+	if estab.SendQ != 4096 || estab.RecvQ != 32 {
+		t.Errorf("expected send_q=4096 recv_q=32, got %d and %d", estab.SendQ, estab.RecvQ)
+	}
+	if estab.Retransmits != 3 {
+		t.Errorf("expected 3 retransmits, got %d", estab.Retransmits)
+	}
+	if estab.Timer != "on" {
+		t.Errorf("expected retransmit timer, got %q", estab.Timer)
+	}
+	if estab.Cwnd != 7 {
+		t.Errorf("expected cwnd 7, got %d", estab.Cwnd)
+	}
+
+	listen := conns[1]
+	if listen.RecvQ != 5 || listen.Timer != "off" || listen.Cwnd != 10 {
+		t.Errorf("unexpected listener metrics: recv_q=%d timer=%q cwnd=%d", listen.RecvQ, listen.Timer, listen.Cwnd)
+	}
+}
+
+func TestParseProcNetUIDFallback(t *testing.T) {
+	path := writeProcNet(t, `  sl  local_address rem_address   st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode
+   0: 00000000:0016 00000000:0000 0A 00000000:00000000 00:00000000 00000000     0        0 100 1 0000000000000000 100 0 0 10 0
+   1: 00000000:1F90 00000000:0000 0A 00000000:00000000 00:00000000 00000000 4242424        0 101 1 0000000000000000 100 0 0 10 0
+   2: 00000000:0050 00000000:0000 0A 00000000:00000000 00:00000000 00000000 4242424        0 102 1 0000000000000000 100 0 0 10 0
+   3: 00000000:01BB 00000000:0000 0A 00000000:00000000 00:00000000 00000000 4242424        0 103 1 0000000000000000 100 0 0 10 0
+`)
+
+	inodeMap := map[int64][]socketOwner{
+		102: {{info: &processInfo{pid: 10, command: "nginx", uid: 33, user: "www-data"}, fds: []int{3}}},
+		// status unreadable, so the process has no user
+		103: {{info: &processInfo{pid: 11, command: "caddy"}, fds: []int{4}}},
+	}
+
+	conns, err := parseProcNet(path, "tcp", 4, inodeMap)
+	if err != nil {
+		t.Fatalf("parseProcNet failed: %v", err)
+	}
+	if len(conns) != 4 {
+		t.Fatalf("expected 4 connections, got %d", len(conns))
+	}
+
+	testCases := []struct {
+		inode  int64
+		user   string
+		uid    int
+		source string
+	}{
+		{100, "root", 0, UIDSourceSocket},
+		{101, "4242424", 4242424, UIDSourceSocket},
+		{102, "www-data", 33, UIDSourceProcess},
+		{103, "4242424", 4242424, UIDSourceSocket},
+	}
+
+	for i, tc := range testCases {
+		c := conns[i]
+		if c.Inode != tc.inode {
+			t.Fatalf("expected inode %d at %d, got %d", tc.inode, i, c.Inode)
+		}
+		if c.User != tc.user || c.UID != tc.uid || c.UIDSource != tc.source {
+			t.Errorf("inode %d: expected %s/%d from %s, got %s/%d from %s",
+				tc.inode, tc.user, tc.uid, tc.source, c.User, c.UID, c.UIDSource)
+		}
+	}
+}
@@ -1,7 +1,10 @@
 package collector
 
 import (
+	"encoding/json"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestGetConnections(t *testing.T) {
@@ -13,4 +16,22 @@ func TestGetConnections(t *testing.T) {
 
 	// connections are dynamic, so just verify function succeeded
 	t.Logf("Successfully got %d connections", len(conns))
-}
\ No newline at end of file
+}
+
+func TestConnectionJSONStartTime(t *testing.T) {
+	unowned, err := json.Marshal(Connection{Inode: 42})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(unowned), "start_time") {
+		t.Errorf("expected no start_time for an unowned socket, got %s", unowned)
+	}
+
+	owned, err := json.Marshal(Connection{PID: 1, StartTime: time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(owned), `"start_time":"2025-01-02T03:04:05Z"`) {
+		t.Errorf("expected start_time, got %s", owned)
+	}
+}
@@ -0,0 +1,137 @@
+//go:build linux
+
+package collector
+
+import (
+	"os"
+	"regexp"
+	"strings"
+)
+
+// containerInfo is the container a process runs in, as derived from its cgroup
+type containerInfo struct {
+	runtime string
+	id      string
+	podUID  string
+	podName string
+}
+
+var (
+	// full 64 character container ids as used by every runtime
+	containerIDPattern = regexp.MustCompile(`[0-9a-f]{64}`)
+
+	// pod uid in cgroupfs (pod1234-...) and systemd (pod1234_...) driver layouts
+	podUIDPattern = regexp.MustCompile(`pod([0-9a-f]{8}[-_][0-9a-f]{4}[-_][0-9a-f]{4}[-_][0-9a-f]{4}[-_][0-9a-f]{12})`)
+)
+
+// cgroup path markers that identify the runtime owning a container id,
+// checked against the path segment holding the id
+var runtimeMarkers = []struct {
+	marker  string
+	runtime string
+}{
+	{"cri-containerd", "containerd"},
+	{"containerd", "containerd"},
+	{"crio", "cri-o"},
+	{"libpod", "podman"},
+	{"docker", "docker"},
+}
+
+// getContainerInfo reads /proc/<pid>/cgroup and, for kubernetes pods, the
+// pod name from the log directory the kubelet keeps for the pod uid
+func getContainerInfo(pid int) containerInfo {
+	data, err := procfs.readPidFile(pid, "cgroup")
+	if err != nil {
+		return containerInfo{}
+	}
+
+	info := parseCgroupContainer(string(data))
+	if info.podUID != "" {
+		info.podName = podNameByUID(info.podUID)
+	}
+	return info
+}
+
+// podNameByUID looks the pod up in the kubelet's /var/log/pods, whose
+// directories are named <namespace>_<name>_<uid>, through the root of pid 1.
+// the environment of the container is not used: its HOSTNAME is the node
+// name for hostNetwork pods and can be overridden. it returns "" when the
+// directory is not readable or has no such pod.
+func podNameByUID(uid string) string {
+	entries, err := os.ReadDir(procfs.pidPath(1, "root", "var", "log", "pods"))
+	if err != nil {
+		return ""
+	}
+
+	for _, entry := range entries {
+		parts := strings.SplitN(entry.Name(), "_", 3)
+		if len(parts) == 3 && parts[2] == uid {
+			return parts[1]
+		}
+	}
+	return ""
+}
+
+// parseCgroupContainer extracts container metadata from /proc/<pid>/cgroup
+// content. it understands docker, podman, containerd, cri-o and kubepods
+// layouts for both the cgroupfs and systemd cgroup drivers.
+func parseCgroupContainer(content string) containerInfo {
+	var info containerInfo
+
+	for _, line := range strings.Split(content, "\n") {
+		// hierarchy-ID:controller-list:cgroup-path
+		parts := strings.SplitN(line, ":", 3)
+		if len(parts) != 3 {
+			continue
+		}
+		path := parts[2]
+
+		if info.podUID == "" && strings.Contains(path, "kubepods") {
+			if m := podUIDPattern.FindStringSubmatch(path); m != nil {
+				info.podUID = strings.ReplaceAll(m[1], "_", "-")
+			}
+		}
+
+		if info.id != "" {
+			continue
+		}
+
+		segments := strings.Split(path, "/")
+		for i := len(segments) - 1; i >= 0; i-- {
+			id := containerIDPattern.FindString(segments[i])
+			if id == "" {
+				continue
+			}
+
+			info.id = id
+			info.runtime = detectRuntime(segments[i], segments[:i])
+			break
+		}
+	}
+
+	if info.runtime == "" && info.podUID != "" {
+		info.runtime = "kubernetes"
+	}
+
+	return info
+}
+
+// detectRuntime names the runtime from the segment holding the container id,
+// falling back to its parent segments (e.g. /docker/<id>)
+func detectRuntime(segment string, parents []string) string {
+	for _, m := range runtimeMarkers {
+		if strings.HasPrefix(segment, m.marker) {
+			return m.runtime
+		}
+	}
+
+	for i := len(parents) - 1; i >= 0; i-- {
+		for _, m := range runtimeMarkers {
+			if strings.HasPrefix(parents[i], m.marker) {
+				return m.runtime
+			}
+		}
+	}
+
+	return ""
+}
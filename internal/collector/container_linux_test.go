@@ -0,0 +1,98 @@
+//go:build linux
+
+package collector
+
+import "testing"
+
+func TestParseCgroupContainer(t *testing.T) {
+	const id = "3f4e2a1b9c8d7e6f5a4b3c2d1e0f9a8b7c6d5e4f3a2b1c0d9e8f7a6b5c4d3e2f"
+
+	tests := []struct {
+		name    string
+		cgroup  string
+		runtime string
+		id      string
+		podUID  string
+	}{
+		{
+			name:   "host process",
+			cgroup: "0::/user.slice/user-1000.slice/session-2.scope\n",
+		},
+		{
+			name:    "docker systemd driver",
+			cgroup:  "0::/system.slice/docker-" + id + ".scope\n",
+			runtime: "docker",
+			id:      id,
+		},
+		{
+			name:    "docker cgroup v1",
+			cgroup:  "12:memory:/docker/" + id + "\n11:cpu,cpuacct:/docker/" + id + "\n",
+			runtime: "docker",
+			id:      id,
+		},
+		{
+			name:    "podman",
+			cgroup:  "0::/machine.slice/libpod-" + id + ".scope/container\n",
+			runtime: "podman",
+			id:      id,
+		},
+		{
+			name:    "kubernetes containerd systemd driver",
+			cgroup:  "0::/kubepods.slice/kubepods-burstable.slice/kubepods-burstable-pod0a1b2c3d_4e5f_6789_abcd_ef0123456789.slice/cri-containerd-" + id + ".scope\n",
+			runtime: "containerd",
+			id:      id,
+			podUID:  "0a1b2c3d-4e5f-6789-abcd-ef0123456789",
+		},
+		{
+			name:    "kubernetes cgroupfs driver",
+			cgroup:  "4:pids:/kubepods/besteffort/pod0a1b2c3d-4e5f-6789-abcd-ef0123456789/" + id + "\n",
+			runtime: "kubernetes",
+			id:      id,
+			podUID:  "0a1b2c3d-4e5f-6789-abcd-ef0123456789",
+		},
+		{
+			name:    "cri-o",
+			cgroup:  "0::/kubepods.slice/kubepods-pod0a1b2c3d_4e5f_6789_abcd_ef0123456789.slice/crio-" + id + ".scope\n",
+			runtime: "cri-o",
+			id:      id,
+			podUID:  "0a1b2c3d-4e5f-6789-abcd-ef0123456789",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info := parseCgroupContainer(tt.cgroup)
+			if info.runtime != tt.runtime {
+				t.Errorf("runtime: got %q, want %q", info.runtime, tt.runtime)
+			}
+			if info.id != tt.id {
+				t.Errorf("id: got %q, want %q", info.id, tt.id)
+			}
+			if info.podUID != tt.podUID {
+				t.Errorf("pod uid: got %q, want %q", info.podUID, tt.podUID)
+			}
+		})
+	}
+}
+
+func TestPodNameByUID(t *testing.T) {
+	const uid = "0a1b2c3d-4e5f-6789-abcd-ef0123456789"
+
+	root := t.TempDir()
+	writeProcTree(t, root, map[string]string{
+		"stat": "cpu  0 0 0 0\nbtime 1700000000\n",
+		"1/root/var/log/pods/default_api-7d9f_" + uid + "/api/0.log":                                  "",
+		"1/root/var/log/pods/kube-system_coredns-5d78_11111111-2222-3333-4444-555555555555/dns/0.log": "",
+	})
+	if err := SetProcRoot(root); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { SetProcRoot(DefaultProcRoot) })
+
+	if name := podNameByUID(uid); name != "api-7d9f" {
+		t.Errorf("expected api-7d9f, got %q", name)
+	}
+	if name := podNameByUID("99999999-2222-3333-4444-555555555555"); name != "" {
+		t.Errorf("expected no name for an unknown pod, got %q", name)
+	}
+}
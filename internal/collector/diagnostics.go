@@ -0,0 +1,156 @@
+package collector
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"strings"
+	"sync"
+)
+
+// Diagnostics reports what a collection could not see, so sockets missing
+// their process can be explained instead of silently left blank
+type Diagnostics struct {
+	// socket tables that exist but could not be read or parsed
+	TableErrors []TableError `json:"table_errors,omitempty"`
+
+	// processes looked at, and how many of their fd tables were unreadable
+	Processes       int `json:"processes"`
+	ProcessesDenied int `json:"processes_denied"`
+
+	// privileges that decide which fd tables are readable. CapDacReadSearch
+	// is also set by CAP_DAC_OVERRIDE, which allows the same listing.
+	Root             bool `json:"root"`
+	CapSysPtrace     bool `json:"cap_sys_ptrace"`
+	CapDacReadSearch bool `json:"cap_dac_read_search"`
+
+	// why the preferred backend was not used, if it was not
+	Fallback string `json:"fallback,omitempty"`
+}
+
+// TableError is a socket table that failed to load
+type TableError struct {
+	Path  string `json:"path"`
+	Error string `json:"error"`
+}
+
+// DiagnosticsReporter is implemented by collectors that report diagnostics
+// for their last collection
+type DiagnosticsReporter interface {
+	Diagnostics() Diagnostics
+}
+
+// GetDiagnostics returns the diagnostics of the global collector's last
+// collection. collectors without diagnostics report none.
+func GetDiagnostics() Diagnostics {
+	dr, ok := globalCollector.(DiagnosticsReporter)
+	if !ok {
+		return Diagnostics{}
+	}
+	return dr.Diagnostics()
+}
+
+// tableFailed records a socket table that could not be read. tables that do
+// not exist are expected, e.g. tcp6 with ipv6 disabled or sctp without its
+// module, and are not reported.
+func (d *Diagnostics) tableFailed(path string, err error) {
+	if d == nil || err == nil || errors.Is(err, fs.ErrNotExist) {
+		return
+	}
+	d.TableErrors = append(d.TableErrors, TableError{Path: path, Error: err.Error()})
+}
+
+// merge adds the findings of another collection, such as the unix socket
+// pass, to d
+func (d *Diagnostics) merge(other Diagnostics) {
+	d.TableErrors = append(d.TableErrors, other.TableErrors...)
+	if other.Processes > d.Processes {
+		d.Processes = other.Processes
+		d.ProcessesDenied = other.ProcessesDenied
+	}
+	d.Root = d.Root || other.Root
+	d.CapSysPtrace = d.CapSysPtrace || other.CapSysPtrace
+	d.CapDacReadSearch = d.CapDacReadSearch || other.CapDacReadSearch
+}
+
+// Warning summarizes the problems in one line, or returns "" when the
+// collection saw everything
+func (d Diagnostics) Warning() string {
+	var parts []string
+
+	if d.ProcessesDenied > 0 {
+		part := fmt.Sprintf("%d of %d processes not accessible", d.ProcessesDenied, d.Processes)
+		if hint := d.privilegeHint(); hint != "" {
+			part += ", " + hint
+		}
+		parts = append(parts, part)
+	}
+	if n := len(d.TableErrors); n == 1 {
+		parts = append(parts, "1 socket table unreadable")
+	} else if n > 1 {
+		parts = append(parts, fmt.Sprintf("%d socket tables unreadable", n))
+	}
+
+	if len(parts) == 0 {
+		return ""
+	}
+	return strings.Join(parts, "; ") + " (--debug for details)"
+}
+
+// Details lists every finding, one per line, for --debug
+func (d Diagnostics) Details() []string {
+	lines := []string{
+		fmt.Sprintf("processes: %d scanned, %d with unreadable fds", d.Processes, d.ProcessesDenied),
+		fmt.Sprintf("privileges: root=%t cap_sys_ptrace=%t cap_dac_read_search=%t", d.Root, d.CapSysPtrace, d.CapDacReadSearch),
+	}
+	if d.Fallback != "" {
+		lines = append(lines, "fell back to /proc: "+d.Fallback)
+	}
+	for _, te := range d.TableErrors {
+		lines = append(lines, fmt.Sprintf("table %s: %s", te.Path, te.Error))
+	}
+	return lines
+}
+
+// privilegeHint suggests the privilege that would show the sockets of
+// inaccessible processes. root needs CAP_SYS_PTRACE to read the fd links of
+// processes with other credentials; other users also need
+// CAP_DAC_READ_SEARCH to list their fd directories.
+func (d Diagnostics) privilegeHint() string {
+	switch {
+	case d.CapSysPtrace && (d.Root || d.CapDacReadSearch):
+		return ""
+	case d.Root, d.CapDacReadSearch:
+		return "CAP_SYS_PTRACE is missing"
+	case d.CapSysPtrace:
+		return "CAP_DAC_READ_SEARCH is missing"
+	default:
+		return "run as root or grant CAP_SYS_PTRACE and CAP_DAC_READ_SEARCH to see their sockets"
+	}
+}
+
+// lastDiagnostics holds a collector's report between collections. sockets
+// and unix sockets are collected separately, so unix findings are merged
+// into the report of the preceding collection.
+type lastDiagnostics struct {
+	mu   sync.Mutex
+	diag Diagnostics
+}
+
+func (l *lastDiagnostics) set(d Diagnostics) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.diag = d
+}
+
+func (l *lastDiagnostics) add(d Diagnostics) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.diag.merge(d)
+}
+
+func (l *lastDiagnostics) get() Diagnostics {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.diag
+}
@@ -0,0 +1,55 @@
+//go:build linux
+
+package collector
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// capabilities from linux/capability.h that decide what snitch can see.
+// listing another user's /proc/<pid>/fd needs CAP_DAC_READ_SEARCH or
+// CAP_DAC_OVERRIDE, reading its links CAP_SYS_PTRACE.
+const (
+	capDacOverride   = 1
+	capDacReadSearch = 2
+	capNetAdmin      = 12
+	capSysPtrace     = 19
+	capSysAdmin      = 21
+)
+
+// newDiagnostics starts a report with the privileges snitch runs with
+func newDiagnostics() *Diagnostics {
+	caps, _ := effectiveCapabilities()
+	return &Diagnostics{
+		Root:             os.Geteuid() == 0,
+		CapSysPtrace:     caps&(1<<capSysPtrace) != 0,
+		CapDacReadSearch: caps&(1<<capDacReadSearch|1<<capDacOverride) != 0,
+	}
+}
+
+// selfStatus is the status file of this process. it is read from the real
+// /proc, since --proc-root may point at the proc filesystem of another pid
+// namespace, where self is a different process or missing.
+var selfStatus = "/proc/self/status"
+
+// effectiveCapabilities returns the CapEff mask of this process
+func effectiveCapabilities() (uint64, error) {
+	file, err := os.Open(selfStatus)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "CapEff:") {
+			return strconv.ParseUint(strings.TrimSpace(line[7:]), 16, 64)
+		}
+	}
+	return 0, fmt.Errorf("no CapEff in %s", file.Name())
+}
@@ -0,0 +1,100 @@
+package collector
+
+import (
+	"errors"
+	"io/fs"
+	"strings"
+	"testing"
+)
+
+func TestDiagnosticsTableFailed(t *testing.T) {
+	var d Diagnostics
+	d.tableFailed("/proc/net/tcp6", fs.ErrNotExist)
+	d.tableFailed("/proc/net/udp", nil)
+	d.tableFailed("/proc/net/tcp", fs.ErrPermission)
+
+	if len(d.TableErrors) != 1 || d.TableErrors[0].Path != "/proc/net/tcp" {
+		t.Errorf("expected only the unreadable tcp table, got %v", d.TableErrors)
+	}
+
+	var missing *Diagnostics
+	missing.tableFailed("/proc/net/tcp", errors.New("boom"))
+}
+
+func TestDiagnosticsWarning(t *testing.T) {
+	testCases := []struct {
+		name     string
+		diag     Diagnostics
+		contains []string
+	}{
+		{
+			name: "nothing to report",
+			diag: Diagnostics{Processes: 120, Root: true, CapSysPtrace: true},
+		},
+		{
+			name:     "unprivileged",
+			diag:     Diagnostics{Processes: 120, ProcessesDenied: 97},
+			contains: []string{"97 of 120 processes not accessible", "run as root"},
+		},
+		{
+			name:     "root without ptrace",
+			diag:     Diagnostics{Processes: 120, ProcessesDenied: 3, Root: true},
+			contains: []string{"3 of 120", "CAP_SYS_PTRACE"},
+		},
+		{
+			name:     "ptrace without root",
+			diag:     Diagnostics{Processes: 120, ProcessesDenied: 2, CapSysPtrace: true},
+			contains: []string{"2 of 120", "CAP_DAC_READ_SEARCH is missing"},
+		},
+		{
+			name:     "dac read search without root",
+			diag:     Diagnostics{Processes: 120, ProcessesDenied: 2, CapDacReadSearch: true},
+			contains: []string{"2 of 120", "CAP_SYS_PTRACE is missing"},
+		},
+		{
+			name:     "ptrace and dac read search without root",
+			diag:     Diagnostics{Processes: 120, ProcessesDenied: 2, CapSysPtrace: true, CapDacReadSearch: true},
+			contains: []string{"2 of 120 processes not accessible (--debug"},
+		},
+		{
+			name: "tables",
+			diag: Diagnostics{
+				Root:         true,
+				CapSysPtrace: true,
+				TableErrors:  []TableError{{Path: "/proc/net/tcp"}, {Path: "/proc/net/udp"}},
+			},
+			contains: []string{"2 socket tables unreadable", "--debug"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			warning := tc.diag.Warning()
+			if len(tc.contains) == 0 && warning != "" {
+				t.Errorf("expected no warning, got %q", warning)
+			}
+			for _, want := range tc.contains {
+				if !strings.Contains(warning, want) {
+					t.Errorf("expected %q in warning %q", want, warning)
+				}
+			}
+		})
+	}
+}
+
+func TestDiagnosticsMerge(t *testing.T) {
+	d := Diagnostics{Processes: 10, ProcessesDenied: 2, TableErrors: []TableError{{Path: "/proc/net/tcp"}}}
+	d.merge(Diagnostics{Processes: 11, ProcessesDenied: 3, TableErrors: []TableError{{Path: "/proc/net/unix"}}})
+
+	if d.Processes != 11 || d.ProcessesDenied != 3 {
+		t.Errorf("expected the larger process scan, got %d/%d", d.ProcessesDenied, d.Processes)
+	}
+	if len(d.TableErrors) != 2 {
+		t.Errorf("expected both table errors, got %v", d.TableErrors)
+	}
+
+	details := strings.Join(d.Details(), "\n")
+	if !strings.Contains(details, "/proc/net/unix") || !strings.Contains(details, "3 with unreadable fds") {
+		t.Errorf("unexpected details:\n%s", details)
+	}
+}
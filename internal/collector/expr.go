@@ -0,0 +1,575 @@
+package collector
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Expr is a compiled filter expression
+type Expr interface {
+	Match(c Connection) bool
+	String() string
+}
+
+type andExpr struct {
+	left, right Expr
+}
+
+func (e *andExpr) Match(c Connection) bool {
+	return e.left.Match(c) && e.right.Match(c)
+}
+
+func (e *andExpr) String() string {
+	return "(" + e.left.String() + " and " + e.right.String() + ")"
+}
+
+type orExpr struct {
+	left, right Expr
+}
+
+func (e *orExpr) Match(c Connection) bool {
+	return e.left.Match(c) || e.right.Match(c)
+}
+
+func (e *orExpr) String() string {
+	return "(" + e.left.String() + " or " + e.right.String() + ")"
+}
+
+type notExpr struct {
+	expr Expr
+}
+
+func (e *notExpr) Match(c Connection) bool {
+	return !e.expr.Match(c)
+}
+
+func (e *notExpr) String() string {
+	return "not " + e.expr.String()
+}
+
+// compareExpr compares one field of a connection with one or more values
+type compareExpr struct {
+	field  string
+	op     string
+	values []string
+	match  func(c Connection) bool
+}
+
+func (e *compareExpr) Match(c Connection) bool {
+	return e.match(c)
+}
+
+func (e *compareExpr) String() string {
+	values := make([]string, len(e.values))
+	for i, v := range e.values {
+		values[i] = quoteValue(v)
+	}
+	if e.op == "in" {
+		return e.field + " in " + strings.Join(values, ",")
+	}
+	return e.field + e.op + values[0]
+}
+
+func quoteValue(v string) string {
+	if v == "" || strings.ContainsAny(v, " \t\"'(),") {
+		return strconv.Quote(v)
+	}
+	return v
+}
+
+// numericFields can be compared with <, <=, > and >=
+var numericFields = map[string]func(c Connection) float64{
+	"pid":      func(c Connection) float64 { return float64(c.PID) },
+	"ppid":     func(c Connection) float64 { return float64(c.PPID) },
+	"uid":      func(c Connection) float64 { return float64(c.UID) },
+	"lport":    func(c Connection) float64 { return float64(c.Lport) },
+	"rport":    func(c Connection) float64 { return float64(c.Rport) },
+	"inode":    func(c Connection) float64 { return float64(c.Inode) },
+	"rtt_ms":   func(c Connection) float64 { return c.RttMs },
+	"rx_bytes": func(c Connection) float64 { return float64(c.RxBytes) },
+	"tx_bytes": func(c Connection) float64 { return float64(c.TxBytes) },
+	"send_q":   func(c Connection) float64 { return float64(c.SendQ) },
+	"recv_q":   func(c Connection) float64 { return float64(c.RecvQ) },
+	"retrans":  func(c Connection) float64 { return float64(c.Retransmits) },
+	"cwnd":     func(c Connection) float64 { return float64(c.Cwnd) },
+}
+
+// textFields can be matched with ~. fields FilterOptions has no key for
+// are compared with = case-insensitively.
+var textFields = map[string]func(c Connection) string{
+	"proto":     func(c Connection) string { return c.Proto },
+	"state":     func(c Connection) string { return c.State },
+	"proc":      func(c Connection) string { return c.Process },
+	"cmdline":   func(c Connection) string { return c.Cmdline },
+	"exe":       func(c Connection) string { return c.Exe },
+	"cwd":       func(c Connection) string { return c.Cwd },
+	"user":      func(c Connection) string { return c.User },
+	"laddr":     func(c Connection) string { return c.Laddr },
+	"raddr":     func(c Connection) string { return c.Raddr },
+	"if":        func(c Connection) string { return c.Interface },
+	"interface": func(c Connection) string { return c.Interface },
+	"mark":      func(c Connection) string { return c.Mark },
+	"namespace": func(c Connection) string { return c.Namespace },
+	"container": func(c Connection) string { return c.ContainerID },
+	"runtime":   func(c Connection) string { return c.ContainerRuntime },
+	"pod":       func(c Connection) string { return c.PodName },
+	"type":      func(c Connection) string { return c.SockType },
+	"ipversion": func(c Connection) string { return c.IPVersion },
+	"timer":     func(c Connection) string { return c.Timer },
+}
+
+// compileCompare builds the matcher of field op values
+func compileCompare(field, op string, values []string) (*compareExpr, error) {
+	e := &compareExpr{field: field, op: op, values: values}
+	key := strings.ToLower(field)
+
+	switch op {
+	case "=", "!=", "in":
+		matchers := make([]func(Connection) bool, len(values))
+		for i, v := range values {
+			m, err := equalsMatcher(key, v)
+			if err != nil {
+				return nil, err
+			}
+			matchers[i] = m
+		}
+		negate := op == "!="
+		e.match = func(c Connection) bool {
+			for _, m := range matchers {
+				if m(c) {
+					return !negate
+				}
+			}
+			return negate
+		}
+
+	case "<", "<=", ">", ">=":
+		get, ok := numericFields[key]
+		if !ok {
+			return nil, fmt.Errorf("%s is not a numeric field and cannot be compared with %s", field, op)
+		}
+		n, err := strconv.ParseFloat(values[0], 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s value: %s", field, values[0])
+		}
+		e.match = func(c Connection) bool {
+			v := get(c)
+			switch op {
+			case "<":
+				return v < n
+			case "<=":
+				return v <= n
+			case ">":
+				return v > n
+			default:
+				return v >= n
+			}
+		}
+
+	case "~", "!~":
+		get, ok := textFields[key]
+		if !ok {
+			num, isNumeric := numericFields[key]
+			if !isNumeric {
+				return nil, fmt.Errorf("%w: %s", errUnknownFilterKey, field)
+			}
+			get = func(c Connection) string { return strconv.FormatFloat(num(c), 'f', -1, 64) }
+		}
+		re, err := regexp.Compile(values[0])
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s regex: %w", field, err)
+		}
+		negate := op == "!~"
+		e.match = func(c Connection) bool {
+			return re.MatchString(get(c)) != negate
+		}
+	}
+
+	return e, nil
+}
+
+// equalsMatcher compiles key=value. keys of FilterOptions keep their
+// semantics, e.g. proto=tcp also matching tcp6 and proc matching a
+// substring. zero values, which FilterOptions reads as unset, and the
+// remaining fields compare exactly, so pid=0 finds unowned sockets.
+func equalsMatcher(key, value string) (func(Connection) bool, error) {
+	var f FilterOptions
+	err := f.Set(key, value)
+	if err != nil && !errors.Is(err, errUnknownFilterKey) {
+		return nil, err
+	}
+	if err == nil && !f.IsEmpty() {
+		return f.Matches, nil
+	}
+
+	if key == "user" {
+		if uid, err := strconv.Atoi(value); err == nil {
+			key, value = "uid", strconv.Itoa(uid)
+		}
+	}
+	if get, ok := numericFields[key]; ok {
+		n, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s value: %s", key, value)
+		}
+		return func(c Connection) bool { return get(c) == n }, nil
+	}
+	if get, ok := textFields[key]; ok {
+		return func(c Connection) bool { return strings.EqualFold(get(c), value) }, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return func(Connection) bool { return true }, nil
+}
+
+// exprWantsUnix reports whether e compares proto with unix anywhere
+func exprWantsUnix(e Expr) bool {
+	switch e := e.(type) {
+	case *andExpr:
+		return exprWantsUnix(e.left) || exprWantsUnix(e.right)
+	case *orExpr:
+		return exprWantsUnix(e.left) || exprWantsUnix(e.right)
+	case *notExpr:
+		return exprWantsUnix(e.expr)
+	case *compareExpr:
+		if strings.ToLower(e.field) != "proto" {
+			return false
+		}
+		for _, v := range e.values {
+			if matchesProto("unix", v) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ParseFilter compiles a filter expression into FilterOptions. expressions
+// that only and together key=value pairs fill the matching fields, like
+// key=value arguments always have; anything else is kept in Expr.
+func ParseFilter(s string) (FilterOptions, error) {
+	var f FilterOptions
+	expr, err := ParseExpr(s)
+	if err != nil || expr == nil {
+		return f, err
+	}
+	if setConjunction(&f, expr, make(map[string]bool)) {
+		return f, nil
+	}
+	return FilterOptions{Expr: expr}, nil
+}
+
+// setConjunction fills f from an and of key=value pairs with distinct keys,
+// and reports false for any other expression
+func setConjunction(f *FilterOptions, e Expr, seen map[string]bool) bool {
+	switch e := e.(type) {
+	case *andExpr:
+		return setConjunction(f, e.left, seen) && setConjunction(f, e.right, seen)
+	case *compareExpr:
+		key := strings.ToLower(e.field)
+		if key == "interface" {
+			key = "if"
+		}
+		if e.op != "=" || seen[key] {
+			return false
+		}
+		seen[key] = true
+
+		var single FilterOptions
+		if single.Set(key, e.values[0]) != nil || single.IsEmpty() {
+			return false
+		}
+		return f.Set(key, e.values[0]) == nil
+	}
+	return false
+}
+
+// ParseExclude compiles an exclude entry. a bare word such as chrome hides
+// that process, anything else is a filter expression like state=TIME_WAIT.
+func ParseExclude(s string) (Expr, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil, fmt.Errorf("empty exclude")
+	}
+	if !strings.ContainsAny(s, " \t=!<>~()") {
+		return compileCompare("proc", "=", []string{s})
+	}
+	return ParseExpr(s)
+}
+
+// ParseExpr compiles a filter expression such as
+//
+//	proto=tcp and (lport in 80,443 or raddr ~ "^10\.") and not proc=chrome
+//
+// comparisons next to each other are and-ed, so key=value lists keep
+// working. an empty expression compiles to nil.
+func ParseExpr(s string) (Expr, error) {
+	p := &exprParser{input: s}
+	p.skipSpace()
+	if p.done() {
+		return nil, nil
+	}
+
+	e, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if !p.done() {
+		return nil, p.errorf("unexpected %q", p.input[p.pos:])
+	}
+	return e, nil
+}
+
+// exprParser is a recursive descent parser over the expression text:
+//
+//	or      = and { "or" and }
+//	and     = unary { ["and"] unary }
+//	unary   = ("not" | "!") unary | "(" or ")" | compare
+//	compare = field op value | field "in" list
+type exprParser struct {
+	input string
+	pos   int
+}
+
+func (p *exprParser) errorf(format string, args ...any) error {
+	return fmt.Errorf("invalid filter expression at position %d: %s", p.pos+1, fmt.Sprintf(format, args...))
+}
+
+func (p *exprParser) done() bool {
+	return p.pos >= len(p.input)
+}
+
+func (p *exprParser) skipSpace() {
+	for !p.done() && isSpace(p.input[p.pos]) {
+		p.pos++
+	}
+}
+
+func isSpace(b byte) bool {
+	return b == ' ' || b == '\t' || b == '\n' || b == '\r'
+}
+
+// keyword consumes kw when it is the next word
+func (p *exprParser) keyword(kw string) bool {
+	end := p.pos + len(kw)
+	if end > len(p.input) || !strings.EqualFold(p.input[p.pos:end], kw) {
+		return false
+	}
+	if end < len(p.input) && !isSpace(p.input[end]) && p.input[end] != '(' && p.input[end] != ')' {
+		return false
+	}
+	p.pos = end
+	return true
+}
+
+func (p *exprParser) consume(s string) bool {
+	if strings.HasPrefix(p.input[p.pos:], s) {
+		p.pos += len(s)
+		return true
+	}
+	return false
+}
+
+func (p *exprParser) parseOr() (Expr, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		p.skipSpace()
+		if !p.keyword("or") {
+			return left, nil
+		}
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &orExpr{left, right}
+	}
+}
+
+func (p *exprParser) parseAnd() (Expr, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		p.skipSpace()
+		if p.done() || p.input[p.pos] == ')' {
+			return left, nil
+		}
+		start := p.pos
+		if p.keyword("or") {
+			p.pos = start
+			return left, nil
+		}
+		p.keyword("and")
+
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = &andExpr{left, right}
+	}
+}
+
+func (p *exprParser) parseUnary() (Expr, error) {
+	p.skipSpace()
+	if p.done() {
+		return nil, p.errorf("expected a comparison")
+	}
+
+	if p.keyword("not") || p.consume("!") {
+		e, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &notExpr{e}, nil
+	}
+
+	if p.consume("(") {
+		e, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		p.skipSpace()
+		if !p.consume(")") {
+			return nil, p.errorf("expected )")
+		}
+		return e, nil
+	}
+
+	return p.parseCompare()
+}
+
+// compareOps are tried in order, so two-character operators win
+var compareOps = []string{"!=", "!~", "<=", ">=", "=", "<", ">", "~"}
+
+func (p *exprParser) parseCompare() (Expr, error) {
+	start := p.pos
+	for !p.done() && isFieldChar(p.input[p.pos]) {
+		p.pos++
+	}
+	field := p.input[start:p.pos]
+	if field == "" {
+		return nil, p.errorf("expected a field name")
+	}
+
+	p.skipSpace()
+	if p.keyword("in") {
+		values, err := p.parseList()
+		if err != nil {
+			return nil, err
+		}
+		return compileCompare(field, "in", values)
+	}
+
+	op := ""
+	for _, candidate := range compareOps {
+		if p.consume(candidate) {
+			op = candidate
+			break
+		}
+	}
+	if op == "" {
+		return nil, p.errorf("expected an operator after %s", field)
+	}
+
+	p.skipSpace()
+	value, err := p.parseValue(false)
+	if err != nil {
+		return nil, err
+	}
+	return compileCompare(field, op, []string{value})
+}
+
+func isFieldChar(b byte) bool {
+	return b == '_' || b >= 'a' && b <= 'z' || b >= 'A' && b <= 'Z' || b >= '0' && b <= '9'
+}
+
+// parseList reads the values of in, either a comma separated word like
+// 80,443 or a parenthesized list like (80, 443)
+func (p *exprParser) parseList() ([]string, error) {
+	p.skipSpace()
+
+	var values []string
+	if !p.consume("(") {
+		quoted := !p.done() && (p.input[p.pos] == '"' || p.input[p.pos] == '\'')
+		value, err := p.parseValue(false)
+		if err != nil {
+			return nil, err
+		}
+		if quoted {
+			return []string{value}, nil
+		}
+		for _, v := range strings.Split(value, ",") {
+			if v != "" {
+				values = append(values, v)
+			}
+		}
+	} else {
+		for {
+			p.skipSpace()
+			if p.consume(")") {
+				break
+			}
+			if p.consume(",") {
+				continue
+			}
+			value, err := p.parseValue(true)
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, value)
+		}
+	}
+
+	if len(values) == 0 {
+		return nil, p.errorf("expected at least one value after in")
+	}
+	return values, nil
+}
+
+// parseValue reads a quoted string, where a backslash only escapes the
+// quote and itself, or a bare word up to whitespace or a parenthesis
+func (p *exprParser) parseValue(inList bool) (string, error) {
+	if p.done() {
+		return "", p.errorf("expected a value")
+	}
+
+	if quote := p.input[p.pos]; quote == '"' || quote == '\'' {
+		p.pos++
+		var b strings.Builder
+		for !p.done() {
+			ch := p.input[p.pos]
+			if ch == '\\' && p.pos+1 < len(p.input) && (p.input[p.pos+1] == quote || p.input[p.pos+1] == '\\') {
+				b.WriteByte(p.input[p.pos+1])
+				p.pos += 2
+				continue
+			}
+			p.pos++
+			if ch == quote {
+				return b.String(), nil
+			}
+			b.WriteByte(ch)
+		}
+		return "", p.errorf("unterminated %c", quote)
+	}
+
+	start := p.pos
+	for !p.done() {
+		ch := p.input[p.pos]
+		if isSpace(ch) || ch == '(' || ch == ')' || inList && ch == ',' {
+			break
+		}
+		p.pos++
+	}
+	if p.pos == start {
+		return "", p.errorf("expected a value")
+	}
+	return p.input[start:p.pos], nil
+}
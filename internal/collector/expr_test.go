@@ -0,0 +1,239 @@
+package collector
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseExprMatches(t *testing.T) {
+	conns := []Connection{
+		{PID: 1, Process: "nginx", Proto: "tcp", State: "LISTEN", Laddr: "*", Lport: 80},
+		{PID: 1, Process: "nginx", Proto: "tcp6", State: "LISTEN", Laddr: "*", Lport: 443},
+		{PID: 2, Process: "chrome", Proto: "tcp", State: "ESTABLISHED", Laddr: "10.0.0.5", Lport: 51000, Raddr: "10.0.0.1", Rport: 443, RttMs: 12.5},
+		{PID: 3, Process: "curl", Proto: "tcp", State: "ESTABLISHED", Laddr: "10.0.0.5", Lport: 51001, Raddr: "93.184.216.34", Rport: 443, RttMs: 180},
+		{PID: 0, Proto: "udp", State: "LISTEN", Laddr: "*", Lport: 53},
+		{PID: 4, Process: "dockerd", Proto: "unix", State: "LISTEN", Laddr: "/run/docker.sock"},
+	}
+
+	testCases := []struct {
+		name     string
+		expr     string
+		expected []int // indexes into conns
+	}{
+		{"single pair", "proto=udp", []int{4}},
+		{"adjacent pairs are and-ed", "proc=nginx lport=443", []int{1}},
+		{"or", "lport=53 or proc=curl", []int{3, 4}},
+		{"and binds tighter than or", "proc=curl or proc=nginx and lport=80", []int{0, 3}},
+		{"parentheses", "(proc=curl or proc=nginx) and lport=80", []int{0}},
+		{"not", "not proto=tcp", []int{4, 5}},
+		{"not equal", "state!=LISTEN", []int{2, 3}},
+		{"not equal substring", "proto=tcp proc!=chrome", []int{0, 1, 3}},
+		{"bang negates a pair", "!raddr=10.0.0.1 and proto=tcp", []int{0, 1, 3}},
+		{"bang negates a group", "!(proto=tcp or proto=udp)", []int{5}},
+		{"in list", "lport in 80,53", []int{0, 4}},
+		{"in parenthesized list", "lport in (80, 53)", []int{0, 4}},
+		{"numeric less than", "lport < 100", []int{0, 4, 5}},
+		{"numeric greater or equal", "rtt_ms >= 100", []int{3}},
+		{"regex", `raddr ~ "^10\."`, []int{2}},
+		{"negated regex", `proto=tcp and proc !~ "^(nginx|curl)$"`, []int{2}},
+		{"pid zero finds unowned sockets", "pid=0", []int{4}},
+		{"keywords are case insensitive", "proc=curl OR NOT proto=tcp", []int{3, 4, 5}},
+		{"field without filter key", "type=stream or exe=/bin/curl", nil},
+		{
+			"request example",
+			`proto=tcp and (lport in 80,443 or raddr ~ "^10\.") and not proc=chrome`,
+			[]int{0, 1},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			expr, err := ParseExpr(tc.expr)
+			if err != nil {
+				t.Fatalf("ParseExpr(%q) failed: %v", tc.expr, err)
+			}
+
+			var got []int
+			for i, c := range conns {
+				if expr.Match(c) {
+					got = append(got, i)
+				}
+			}
+			if len(got) != len(tc.expected) {
+				t.Fatalf("%s: expected %v, got %v", expr, tc.expected, got)
+			}
+			for i := range got {
+				if got[i] != tc.expected[i] {
+					t.Fatalf("%s: expected %v, got %v", expr, tc.expected, got)
+				}
+			}
+		})
+	}
+}
+
+func TestParseExprString(t *testing.T) {
+	testCases := []struct {
+		expr     string
+		expected string
+	}{
+		{"proc=a or proc=b and lport=1", "(proc=a or (proc=b and lport=1))"},
+		{"not (proc=a or proc=b)", "not (proc=a or proc=b)"},
+		{`proc="my app"`, `proc="my app"`},
+		{"lport in 80,443", "lport in 80,443"},
+		{`raddr ~ '^10\.'`, `raddr~^10\.`},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.expr, func(t *testing.T) {
+			expr, err := ParseExpr(tc.expr)
+			if err != nil {
+				t.Fatalf("ParseExpr failed: %v", err)
+			}
+			if expr.String() != tc.expected {
+				t.Errorf("expected %s, got %s", tc.expected, expr.String())
+			}
+		})
+	}
+}
+
+func TestParseExprErrors(t *testing.T) {
+	testCases := []struct {
+		expr    string
+		errText string
+	}{
+		{"proto", "expected an operator after proto"},
+		{"proto=", "expected a value"},
+		{"(proto=tcp", "expected )"},
+		{"proto=tcp)", "unexpected"},
+		{"proto=tcp or", "expected a comparison"},
+		{`proc="nginx`, "unterminated"},
+		{"badkey=1", "unknown filter key: badkey"},
+		{"pid=abc", "invalid pid value"},
+		{"proc > 3", "not a numeric field"},
+		{"raddr ~ (", "expected a value"},
+		{`raddr ~ "("`, "invalid raddr regex"},
+		{"lport in ()", "at least one value"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.expr, func(t *testing.T) {
+			_, err := ParseExpr(tc.expr)
+			if err == nil {
+				t.Fatalf("expected an error")
+			}
+			if !strings.Contains(err.Error(), tc.errText) {
+				t.Errorf("expected error containing %q, got %q", tc.errText, err)
+			}
+		})
+	}
+}
+
+func TestParseFilterCompilesPairs(t *testing.T) {
+	t.Run("pairs fill the fields", func(t *testing.T) {
+		f, err := ParseFilter("proto=tcp and state=listen lport=80")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if f.Expr != nil || f.Proto != "tcp" || f.State != "listen" || f.Lport != 80 {
+			t.Errorf("expected plain filter options, got %+v", f)
+		}
+	})
+
+	testCases := []string{
+		"proto=tcp or proto=udp",
+		"lport=80 lport=443",
+		"pid=0",
+		"lport<1024",
+		"exe=/usr/bin/ssh",
+	}
+	for _, s := range testCases {
+		t.Run(s, func(t *testing.T) {
+			f, err := ParseFilter(s)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if f.Expr == nil {
+				t.Errorf("expected %q to be kept as an expression, got %+v", s, f)
+			}
+		})
+	}
+
+	t.Run("empty", func(t *testing.T) {
+		f, err := ParseFilter("  ")
+		if err != nil || !f.IsEmpty() {
+			t.Errorf("expected empty filter, got %+v (%v)", f, err)
+		}
+	})
+}
+
+func TestWantsUnixFromExpr(t *testing.T) {
+	testCases := []struct {
+		expr     string
+		expected bool
+	}{
+		{"proto=unix", true},
+		{"proto=tcp or proto=unix", true},
+		{"proto in tcp,unix", true},
+		{"proto=tcp or lport=80", false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.expr, func(t *testing.T) {
+			f, err := ParseFilter(tc.expr)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if f.WantsUnix() != tc.expected {
+				t.Errorf("expected WantsUnix() = %t", tc.expected)
+			}
+		})
+	}
+}
+
+func TestParseExclude(t *testing.T) {
+	conns := []Connection{
+		{Process: "chrome", Proto: "tcp", State: "ESTABLISHED"},
+		{Process: "chrome_crashpad", Proto: "tcp", State: "ESTABLISHED"},
+		{Process: "curl", Proto: "tcp", State: "TIME_WAIT"},
+		{Process: "sshd", Proto: "tcp", State: "LISTEN"},
+	}
+
+	testCases := []struct {
+		excludes []string
+		expected []string
+	}{
+		{[]string{"chrome"}, []string{"curl", "sshd"}},
+		{[]string{"state=TIME_WAIT"}, []string{"chrome", "chrome_crashpad", "sshd"}},
+		{[]string{"chrome", "state=TIME_WAIT"}, []string{"sshd"}},
+		{[]string{"proc=chrome and state=LISTEN"}, []string{"chrome", "chrome_crashpad", "curl", "sshd"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(strings.Join(tc.excludes, ","), func(t *testing.T) {
+			var f FilterOptions
+			for _, s := range tc.excludes {
+				expr, err := ParseExclude(s)
+				if err != nil {
+					t.Fatalf("ParseExclude(%q) failed: %v", s, err)
+				}
+				f.Exclude = append(f.Exclude, expr)
+			}
+
+			filtered := FilterConnections(conns, f)
+			if len(filtered) != len(tc.expected) {
+				t.Fatalf("expected %v, got %v", tc.expected, filtered)
+			}
+			for i, c := range filtered {
+				if c.Process != tc.expected[i] {
+					t.Errorf("expected %v, got %s at %d", tc.expected, c.Process, i)
+				}
+			}
+		})
+	}
+
+	for _, s := range []string{"", "  ", "proc=(", "nope=1"} {
+		if _, err := ParseExclude(s); err == nil {
+			t.Errorf("expected ParseExclude(%q) to fail", s)
+		}
+	}
+}
@@ -1,15 +1,22 @@
 package collector
 
 import (
+	"errors"
+	"fmt"
+	"net/netip"
+	"strconv"
 	"strings"
 	"time"
 )
 
+var errUnknownFilterKey = errors.New("unknown filter key")
+
 type FilterOptions struct {
 	Proto     string
 	State     string
 	Pid       int
 	Proc      string
+	Cmdline   string
 	Lport     int
 	Rport     int
 	User      string
@@ -22,17 +29,31 @@ type FilterOptions struct {
 	Interface string
 	Mark      string
 	Namespace string
+	Container string
 	Inode     int64
 	Since     time.Time
 	SinceRel  time.Duration
+
+	// port ranges and lists such as lport=8000-8999 or rport=443,8443.
+	// a single port is kept in Lport and Rport.
+	Lports []PortRange
+	Rports []PortRange
+
+	// Expr holds filter expressions beyond the key=value fields above, e.g.
+	// ones using or, not or comparisons. both have to match.
+	Expr Expr
+
+	// connections matching any of these are hidden, e.g. from --exclude
+	Exclude []Expr
 }
 
 func (f *FilterOptions) IsEmpty() bool {
-	return f.Proto == "" && f.State == "" && f.Pid == 0 && f.Proc == "" &&
+	return f.Proto == "" && f.State == "" && f.Pid == 0 && f.Proc == "" && f.Cmdline == "" &&
 		f.Lport == 0 && f.Rport == 0 && f.User == "" && f.UID == 0 &&
 		f.Laddr == "" && f.Raddr == "" && f.Contains == "" &&
-		f.Interface == "" && f.Mark == "" && f.Namespace == "" && f.Inode == 0 &&
-		f.Since.IsZero() && f.SinceRel == 0 && !f.IPv4 && !f.IPv6
+		f.Interface == "" && f.Mark == "" && f.Namespace == "" && f.Container == "" && f.Inode == 0 &&
+		f.Since.IsZero() && f.SinceRel == 0 && !f.IPv4 && !f.IPv6 &&
+		len(f.Lports) == 0 && len(f.Rports) == 0 && f.Expr == nil && len(f.Exclude) == 0
 }
 
 func (f *FilterOptions) Matches(c Connection) bool {
@@ -42,28 +63,37 @@ func (f *FilterOptions) Matches(c Connection) bool {
 	if f.State != "" && !strings.EqualFold(c.State, f.State) {
 		return false
 	}
-	if f.Pid != 0 && c.PID != f.Pid {
+	if f.Pid != 0 && !matchesPid(c, f.Pid) {
 		return false
 	}
 	if f.Proc != "" && !containsIgnoreCase(c.Process, f.Proc) {
 		return false
 	}
+	if f.Cmdline != "" && !containsIgnoreCase(c.Cmdline, f.Cmdline) {
+		return false
+	}
 	if f.Lport != 0 && c.Lport != f.Lport {
 		return false
 	}
 	if f.Rport != 0 && c.Rport != f.Rport {
 		return false
 	}
+	if len(f.Lports) > 0 && !matchesPortRanges(c.Lport, f.Lports) {
+		return false
+	}
+	if len(f.Rports) > 0 && !matchesPortRanges(c.Rport, f.Rports) {
+		return false
+	}
 	if f.User != "" && !strings.EqualFold(c.User, f.User) {
 		return false
 	}
 	if f.UID != 0 && c.UID != f.UID {
 		return false
 	}
-	if f.Laddr != "" && !strings.EqualFold(c.Laddr, f.Laddr) {
+	if f.Laddr != "" && !matchesAddr(c, c.Laddr, f.Laddr) {
 		return false
 	}
-	if f.Raddr != "" && !strings.EqualFold(c.Raddr, f.Raddr) {
+	if f.Raddr != "" && !matchesAddr(c, c.Raddr, f.Raddr) {
 		return false
 	}
 	if f.Contains != "" && !matchesContains(c, f.Contains) {
@@ -81,7 +111,10 @@ func (f *FilterOptions) Matches(c Connection) bool {
 	if f.Mark != "" && !strings.EqualFold(c.Mark, f.Mark) {
 		return false
 	}
-	if f.Namespace != "" && !strings.EqualFold(c.Namespace, f.Namespace) {
+	if f.Namespace != "" && !matchesNamespace(c, f.Namespace) {
+		return false
+	}
+	if f.Container != "" && !matchesContainer(c, f.Container) {
 		return false
 	}
 	if f.Inode != 0 && c.Inode != f.Inode {
@@ -96,36 +129,254 @@ func (f *FilterOptions) Matches(c Connection) bool {
 			return false
 		}
 	}
+	if f.Expr != nil && !f.Expr.Match(c) {
+		return false
+	}
+	for _, exclude := range f.Exclude {
+		if exclude.Match(c) {
+			return false
+		}
+	}
 
 	return true
 }
 
+// Set applies a single key=value filter
+func (f *FilterOptions) Set(key, value string) error {
+	switch strings.ToLower(key) {
+	case "proto":
+		f.Proto = value
+	case "state":
+		f.State = value
+	case "pid":
+		pid, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("invalid pid value: %s", value)
+		}
+		f.Pid = pid
+	case "proc":
+		f.Proc = value
+	case "cmdline":
+		f.Cmdline = value
+	case "lport":
+		ranges, err := ParsePortRanges(value)
+		if err != nil {
+			return fmt.Errorf("invalid lport value: %s", value)
+		}
+		f.Lport, f.Lports = splitSinglePort(ranges)
+	case "rport":
+		ranges, err := ParsePortRanges(value)
+		if err != nil {
+			return fmt.Errorf("invalid rport value: %s", value)
+		}
+		f.Rport, f.Rports = splitSinglePort(ranges)
+	case "user":
+		uid, err := strconv.Atoi(value)
+		if err == nil {
+			f.UID = uid
+		} else {
+			f.User = value
+		}
+	case "laddr":
+		if err := validateAddrFilter(value); err != nil {
+			return fmt.Errorf("invalid laddr value: %s", value)
+		}
+		f.Laddr = value
+	case "raddr":
+		if err := validateAddrFilter(value); err != nil {
+			return fmt.Errorf("invalid raddr value: %s", value)
+		}
+		f.Raddr = value
+	case "contains":
+		f.Contains = value
+	case "if", "interface":
+		f.Interface = value
+	case "mark":
+		f.Mark = value
+	case "namespace":
+		f.Namespace = value
+	case "container":
+		f.Container = value
+	case "inode":
+		inode, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid inode value: %s", value)
+		}
+		f.Inode = inode
+	case "since":
+		since, sinceRel, err := ParseTimeFilter(value)
+		if err != nil {
+			return fmt.Errorf("invalid since value: %s", value)
+		}
+		f.Since = since
+		f.SinceRel = sinceRel
+	default:
+		return fmt.Errorf("%w: %s", errUnknownFilterKey, key)
+	}
+	return nil
+}
+
 func containsIgnoreCase(s, substr string) bool {
 	return strings.Contains(strings.ToLower(s), strings.ToLower(substr))
 }
 
 // checks if a connection's protocol matches the filter.
-// treats "tcp" as matching "tcp" and "tcp6", same for "udp"/"udp6"
+// treats "tcp" as matching "tcp" and "tcp6", same for the other families
+// with a separate ipv6 table (udp, udplite, raw, icmp). a comma separated
+// list such as "tcp,unix" matches any of its protocols.
 func matchesProto(connProto, filterProto string) bool {
 	connLower := strings.ToLower(connProto)
-	filterLower := strings.ToLower(filterProto)
 
-	// exact match
-	if connLower == filterLower {
+	for _, filterLower := range strings.Split(strings.ToLower(filterProto), ",") {
+		filterLower = strings.TrimSpace(filterLower)
+
+		// exact match
+		if connLower == filterLower {
+			return true
+		}
+
+		// "tcp" matches both "tcp" and "tcp6", likewise udp, raw, udplite and icmp
+		if connLower == filterLower+"6" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// WantsUnix reports whether the proto filter asks for unix domain sockets,
+// which are only collected on request
+func (f FilterOptions) WantsUnix() bool {
+	if f.Proto != "" && matchesProto("unix", f.Proto) {
 		return true
 	}
+	return f.Expr != nil && exprWantsUnix(f.Expr)
+}
+
+// PortRange is an inclusive range of ports
+type PortRange struct {
+	From int
+	To   int
+}
+
+// ParsePortRanges parses a port, a range like 8000-8999, or a comma
+// separated list of both
+func ParsePortRanges(s string) ([]PortRange, error) {
+	var ranges []PortRange
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		from, to, isRange := strings.Cut(part, "-")
+		if !isRange {
+			to = from
+		}
+
+		lo, err := strconv.Atoi(from)
+		if err != nil {
+			return nil, err
+		}
+		hi, err := strconv.Atoi(to)
+		if err != nil {
+			return nil, err
+		}
+		if lo < 0 || hi > 65535 || lo > hi {
+			return nil, fmt.Errorf("invalid port range %s", part)
+		}
+		ranges = append(ranges, PortRange{From: lo, To: hi})
+	}
+	return ranges, nil
+}
+
+// splitSinglePort keeps a lone port in the plain int field, so existing
+// callers comparing Lport and Rport keep working
+func splitSinglePort(ranges []PortRange) (int, []PortRange) {
+	if len(ranges) == 1 && ranges[0].From == ranges[0].To {
+		return ranges[0].From, nil
+	}
+	return 0, ranges
+}
+
+func matchesPortRanges(port int, ranges []PortRange) bool {
+	for _, r := range ranges {
+		if port >= r.From && port <= r.To {
+			return true
+		}
+	}
+	return false
+}
+
+// validateAddrFilter rejects malformed CIDR prefixes. anything without a
+// slash is compared as a string, like hostnames and "*".
+func validateAddrFilter(value string) error {
+	if !strings.Contains(value, "/") {
+		return nil
+	}
+	_, err := netip.ParsePrefix(value)
+	return err
+}
+
+// matchesAddr compares an address with a filter value. a CIDR prefix such as
+// 10.0.0.0/8 or fd00::/8 matches every address inside it, including ipv4
+// addresses mapped into ipv6 and "*", the wildcard of the socket's family;
+// anything else has to be equal.
+func matchesAddr(c Connection, addr, filter string) bool {
+	if !strings.Contains(filter, "/") {
+		return strings.EqualFold(addr, filter)
+	}
+
+	prefix, err := netip.ParsePrefix(filter)
+	if err != nil {
+		return false
+	}
+
+	var ip netip.Addr
+	if addr == "*" {
+		ip = netip.IPv4Unspecified()
+		if c.IPVersion == "IPv6" {
+			ip = netip.IPv6Unspecified()
+		}
+	} else if ip, err = netip.ParseAddr(addr); err != nil {
+		return false
+	}
+
+	ip = ip.WithZone("")
+	if prefix.Addr().Is4() {
+		ip = ip.Unmap()
+	}
+	return prefix.Contains(ip)
+}
 
-	// "tcp" matches both "tcp" and "tcp6"
-	if filterLower == "tcp" && (connLower == "tcp" || connLower == "tcp6") {
+// matchesPid accepts any process holding the socket, not just the one shown
+func matchesPid(c Connection, pid int) bool {
+	if c.PID == pid {
 		return true
 	}
+	for _, owner := range c.Owners {
+		if owner.PID == pid {
+			return true
+		}
+	}
+	return false
+}
 
-	// "udp" matches both "udp" and "udp6"
-	if filterLower == "udp" && (connLower == "udp" || connLower == "udp6") {
+// matchesNamespace accepts either the namespace's friendly name or its inode
+func matchesNamespace(c Connection, namespace string) bool {
+	if strings.EqualFold(c.Namespace, namespace) {
 		return true
 	}
+	return c.NamespaceInode != 0 && strconv.FormatInt(c.NamespaceInode, 10) == namespace
+}
 
-	return false
+// matchesContainer accepts a container id prefix (like docker's short ids),
+// a runtime name, or a kubernetes pod name or uid
+func matchesContainer(c Connection, query string) bool {
+	if c.ContainerID == "" && c.PodUID == "" {
+		return false
+	}
+	q := strings.ToLower(query)
+	return (c.ContainerID != "" && strings.HasPrefix(c.ContainerID, q)) ||
+		strings.EqualFold(c.ContainerRuntime, q) ||
+		strings.EqualFold(c.PodName, q) ||
+		strings.EqualFold(c.PodUID, q)
 }
 
 func matchesContains(c Connection, query string) bool {
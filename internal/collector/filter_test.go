@@ -41,4 +41,264 @@ func TestFilterConnections(t *testing.T) {
 			}
 		})
 	}
-}
\ No newline at end of file
+}
+
+func TestFilterByNamespace(t *testing.T) {
+	conns := []Connection{
+		{Process: "host", Namespace: "init", NamespaceInode: 4026531840},
+		{Process: "sandbox", Namespace: "blue", NamespaceInode: 4026532201},
+		{Process: "container", NamespaceInode: 4026532305},
+	}
+
+	testCases := []struct {
+		name     string
+		value    string
+		expected string
+	}{
+		{"by name", "blue", "sandbox"},
+		{"by name case insensitive", "INIT", "host"},
+		{"by inode", "4026532305", "container"},
+		{"by inode of named namespace", "4026532201", "sandbox"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			filtered := FilterConnections(conns, FilterOptions{Namespace: tc.value})
+			if len(filtered) != 1 || filtered[0].Process != tc.expected {
+				t.Errorf("expected only %s, got %v", tc.expected, filtered)
+			}
+		})
+	}
+}
+
+func TestFilterByContainer(t *testing.T) {
+	conns := []Connection{
+		{Process: "host"},
+		{Process: "web", ContainerRuntime: "docker", ContainerID: "3f4e2a1b9c8d7e6f"},
+		{Process: "api", ContainerRuntime: "containerd", ContainerID: "aa11bb22cc33dd44", PodUID: "0a1b2c3d-4e5f", PodName: "api-7d9f"},
+	}
+
+	testCases := []struct {
+		name     string
+		value    string
+		expected []string
+	}{
+		{"by short id", "3f4e2a1b9c8d", []string{"web"}},
+		{"by runtime", "containerd", []string{"api"}},
+		{"by pod name", "api-7d9f", []string{"api"}},
+		{"by pod uid", "0a1b2c3d-4e5f", []string{"api"}},
+		{"no match", "ffff", nil},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			filtered := FilterConnections(conns, FilterOptions{Container: tc.value})
+			if len(filtered) != len(tc.expected) {
+				t.Fatalf("expected %v, got %v", tc.expected, filtered)
+			}
+			for i, want := range tc.expected {
+				if filtered[i].Process != want {
+					t.Errorf("expected %s, got %s", want, filtered[i].Process)
+				}
+			}
+		})
+	}
+}
+
+func TestFilterByPidMatchesAnyOwner(t *testing.T) {
+	conns := []Connection{
+		{PID: 300, Process: "nginx", Owners: []Owner{{PID: 300}, {PID: 301}, {PID: 302}}},
+		{PID: 400, Process: "sshd"},
+	}
+
+	for _, pid := range []int{300, 302} {
+		filtered := FilterConnections(conns, FilterOptions{Pid: pid})
+		if len(filtered) != 1 || filtered[0].Process != "nginx" {
+			t.Errorf("pid=%d: expected the shared nginx listener, got %v", pid, filtered)
+		}
+	}
+
+	if filtered := FilterConnections(conns, FilterOptions{Pid: 303}); len(filtered) != 0 {
+		t.Errorf("expected no match for pid 303, got %v", filtered)
+	}
+}
+
+func TestFilterByCmdline(t *testing.T) {
+	conns := []Connection{
+		{Process: "python3", Cmdline: "/usr/bin/python3 manage.py runserver"},
+		{Process: "python3", Cmdline: "/usr/bin/python3 -m http.server"},
+		{Process: "nginx"},
+	}
+
+	filtered := FilterConnections(conns, FilterOptions{Cmdline: "MANAGE.PY"})
+	if len(filtered) != 1 || filtered[0].Cmdline != conns[0].Cmdline {
+		t.Errorf("expected only the manage.py process, got %v", filtered)
+	}
+
+	opts := FilterOptions{Cmdline: "x"}
+	if opts.IsEmpty() {
+		t.Error("cmdline filter should not be empty")
+	}
+}
+
+func TestMatchesProto(t *testing.T) {
+	testCases := []struct {
+		conn   string
+		filter string
+		want   bool
+	}{
+		{"tcp6", "tcp", true},
+		{"udp6", "udp", true},
+		{"raw6", "raw", true},
+		{"icmp6", "icmp", true},
+		{"udplite6", "udplite", true},
+		{"udplite", "udp", false},
+		{"packet", "packet", true},
+		{"sctp", "SCTP", true},
+		{"tcp", "tcp6", false},
+		{"unix", "tcp,unix", true},
+		{"udp6", "tcp, udp", true},
+		{"raw", "tcp,unix", false},
+	}
+
+	for _, tc := range testCases {
+		if got := matchesProto(tc.conn, tc.filter); got != tc.want {
+			t.Errorf("matchesProto(%q, %q) = %v, want %v", tc.conn, tc.filter, got, tc.want)
+		}
+	}
+}
+
+func TestWantsUnix(t *testing.T) {
+	testCases := []struct {
+		proto string
+		want  bool
+	}{
+		{"", false},
+		{"tcp", false},
+		{"unix", true},
+		{"tcp,unix", true},
+	}
+
+	for _, tc := range testCases {
+		if got := (FilterOptions{Proto: tc.proto}).WantsUnix(); got != tc.want {
+			t.Errorf("WantsUnix() with proto %q = %v, want %v", tc.proto, got, tc.want)
+		}
+	}
+}
+
+func TestFilterByCIDR(t *testing.T) {
+	conns := []Connection{
+		{Process: "lan", IPVersion: "IPv4", Laddr: "192.168.1.10", Raddr: "10.1.2.3"},
+		{Process: "wan", IPVersion: "IPv4", Laddr: "192.168.1.10", Raddr: "93.184.216.34"},
+		{Process: "mapped", IPVersion: "IPv6", Laddr: "::ffff:192.168.1.10", Raddr: "::ffff:10.9.9.9"},
+		{Process: "ula", IPVersion: "IPv6", Laddr: "fd12:3456::1", Raddr: "fe80::1%eth0"},
+		{Process: "any4", IPVersion: "IPv4", Laddr: "*", Raddr: "*"},
+		{Process: "named", IPVersion: "IPv4", Laddr: "localhost", Raddr: "example.com"},
+	}
+
+	testCases := []struct {
+		name     string
+		key      string
+		value    string
+		expected []string
+	}{
+		{"ipv4 prefix", "raddr", "10.0.0.0/8", []string{"lan", "mapped"}},
+		{"ipv6 prefix", "laddr", "fd00::/8", []string{"ula"}},
+		{"link local with zone", "raddr", "fe80::/10", []string{"ula"}},
+		{"wildcard is the unspecified address", "laddr", "0.0.0.0/32", []string{"any4"}},
+		{"host prefix", "raddr", "93.184.216.34/32", []string{"wan"}},
+		{"plain address still compares exactly", "raddr", "10.1.2.3", []string{"lan"}},
+		{"hostname still compares exactly", "raddr", "EXAMPLE.com", []string{"named"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var f FilterOptions
+			if err := f.Set(tc.key, tc.value); err != nil {
+				t.Fatal(err)
+			}
+			filtered := FilterConnections(conns, f)
+			if len(filtered) != len(tc.expected) {
+				t.Fatalf("expected %v, got %v", tc.expected, filtered)
+			}
+			for i, c := range filtered {
+				if c.Process != tc.expected[i] {
+					t.Errorf("expected %v, got %s at %d", tc.expected, c.Process, i)
+				}
+			}
+		})
+	}
+}
+
+func TestFilterByPortRange(t *testing.T) {
+	conns := []Connection{
+		{Process: "a", Lport: 80, Rport: 443},
+		{Process: "b", Lport: 8000, Rport: 8443},
+		{Process: "c", Lport: 8999, Rport: 22},
+		{Process: "d", Lport: 9000, Rport: 0},
+	}
+
+	testCases := []struct {
+		key      string
+		value    string
+		expected int
+	}{
+		{"lport", "8000-8999", 2},
+		{"lport", "80,9000", 2},
+		{"lport", "80,8000-8999", 3},
+		{"rport", "443,8443", 2},
+		{"rport", "22", 1},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.key+"="+tc.value, func(t *testing.T) {
+			var f FilterOptions
+			if err := f.Set(tc.key, tc.value); err != nil {
+				t.Fatal(err)
+			}
+			if filtered := FilterConnections(conns, f); len(filtered) != tc.expected {
+				t.Errorf("expected %d connections, got %d", tc.expected, len(filtered))
+			}
+		})
+	}
+}
+
+func TestParsePortRanges(t *testing.T) {
+	testCases := []struct {
+		value   string
+		want    []PortRange
+		wantErr bool
+	}{
+		{"443", []PortRange{{443, 443}}, false},
+		{"8000-8999", []PortRange{{8000, 8999}}, false},
+		{"443, 8443,9000-9100", []PortRange{{443, 443}, {8443, 8443}, {9000, 9100}}, false},
+		{"9000-8000", nil, true},
+		{"70000", nil, true},
+		{"http", nil, true},
+		{"80-", nil, true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.value, func(t *testing.T) {
+			got, err := ParsePortRanges(tc.value)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("ParsePortRanges(%q) error = %v, wantErr %v", tc.value, err, tc.wantErr)
+			}
+			if len(got) != len(tc.want) {
+				t.Fatalf("expected %v, got %v", tc.want, got)
+			}
+			for i := range got {
+				if got[i] != tc.want[i] {
+					t.Errorf("expected %v, got %v", tc.want, got)
+				}
+			}
+		})
+	}
+}
+
+func TestSetRejectsInvalidCIDR(t *testing.T) {
+	var f FilterOptions
+	if err := f.Set("raddr", "10.0.0.0/33"); err == nil {
+		t.Error("expected an error for an invalid prefix")
+	}
+}
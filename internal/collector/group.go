@@ -0,0 +1,122 @@
+package collector
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Group aggregates the connections that share the values of the grouped
+// fields
+type Group struct {
+	// the grouped fields and their values
+	Key map[string]string `json:"key"`
+
+	Count   int   `json:"count"`
+	RxBytes int64 `json:"rx_bytes"`
+	TxBytes int64 `json:"tx_bytes"`
+
+	// rtt averaged over the connections that report one
+	AvgRttMs float64 `json:"avg_rtt_ms"`
+
+	values   []string
+	rttSum   float64
+	rttCount int
+}
+
+// groupKey returns the function that extracts the value connections are
+// grouped by for field. process is accepted next to proc, as in --fields.
+func groupKey(field string) (func(c Connection) string, bool) {
+	key := strings.ToLower(field)
+	if key == "process" {
+		key = "proc"
+	}
+	if get, ok := textFields[key]; ok {
+		return get, true
+	}
+	if get, ok := numericFields[key]; ok {
+		return func(c Connection) string {
+			return strconv.FormatFloat(get(c), 'f', -1, 64)
+		}, true
+	}
+	return nil, false
+}
+
+// ParseGroupFields parses a comma-separated list of fields to group by,
+// like "raddr,process"
+func ParseGroupFields(s string) ([]string, error) {
+	var fields []string
+	for _, field := range strings.Split(s, ",") {
+		field = strings.ToLower(strings.TrimSpace(field))
+		if field == "" {
+			continue
+		}
+		if _, ok := groupKey(field); !ok {
+			return nil, fmt.Errorf("cannot group by %q", field)
+		}
+		fields = append(fields, field)
+	}
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("no fields to group by")
+	}
+	return fields, nil
+}
+
+// GroupConnections aggregates conns by the values of fields. groups are
+// ordered by count, largest first, and then by their key.
+func GroupConnections(conns []Connection, fields []string) ([]Group, error) {
+	getters := make([]func(Connection) string, len(fields))
+	for i, field := range fields {
+		get, ok := groupKey(field)
+		if !ok {
+			return nil, fmt.Errorf("cannot group by %q", field)
+		}
+		getters[i] = get
+	}
+
+	index := make(map[string]int)
+	groups := []Group{}
+	for _, c := range conns {
+		values := make([]string, len(getters))
+		for i, get := range getters {
+			values[i] = get(c)
+		}
+		id := strings.Join(values, "\x00")
+
+		i, ok := index[id]
+		if !ok {
+			key := make(map[string]string, len(fields))
+			for j, field := range fields {
+				key[field] = values[j]
+			}
+			i = len(groups)
+			index[id] = i
+			groups = append(groups, Group{Key: key, values: values})
+		}
+
+		g := &groups[i]
+		g.Count++
+		g.RxBytes += c.RxBytes
+		g.TxBytes += c.TxBytes
+		if c.RttMs > 0 {
+			g.rttSum += c.RttMs
+			g.rttCount++
+		}
+	}
+
+	sort.SliceStable(groups, func(i, j int) bool {
+		if groups[i].Count != groups[j].Count {
+			return groups[i].Count > groups[j].Count
+		}
+		return slices.Compare(groups[i].values, groups[j].values) < 0
+	})
+
+	for i := range groups {
+		if groups[i].rttCount > 0 {
+			groups[i].AvgRttMs = groups[i].rttSum / float64(groups[i].rttCount)
+		}
+	}
+	return groups, nil
+}
@@ -0,0 +1,106 @@
+package collector
+
+import (
+	"testing"
+)
+
+func TestGroupConnections(t *testing.T) {
+	conns := []Connection{
+		{Process: "nginx", Raddr: "10.0.0.1", Lport: 443, RxBytes: 100, TxBytes: 10, RttMs: 2},
+		{Process: "nginx", Raddr: "10.0.0.1", Lport: 443, RxBytes: 200, TxBytes: 20, RttMs: 4},
+		{Process: "nginx", Raddr: "10.0.0.2", Lport: 443, RxBytes: 50, TxBytes: 5},
+		{Process: "curl", Raddr: "10.0.0.1", Lport: 50000, RxBytes: 1, TxBytes: 1, RttMs: 9},
+	}
+
+	t.Run("group by raddr and process", func(t *testing.T) {
+		groups, err := GroupConnections(conns, []string{"raddr", "process"})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(groups) != 3 {
+			t.Fatalf("expected 3 groups, got %d", len(groups))
+		}
+
+		g := groups[0]
+		if g.Key["raddr"] != "10.0.0.1" || g.Key["process"] != "nginx" {
+			t.Errorf("expected the largest group first, got %v", g.Key)
+		}
+		if g.Count != 2 || g.RxBytes != 300 || g.TxBytes != 30 {
+			t.Errorf("expected count 2, rx 300, tx 30, got %d, %d, %d", g.Count, g.RxBytes, g.TxBytes)
+		}
+		if g.rttSum != 6 || g.AvgRttMs != 3 {
+			t.Errorf("expected rtt sum 6 and avg 3, got %v and %v", g.rttSum, g.AvgRttMs)
+		}
+
+		// equal counts are ordered by key
+		if groups[1].Key["raddr"] != "10.0.0.1" || groups[1].Key["process"] != "curl" {
+			t.Errorf("expected 10.0.0.1/curl second, got %v", groups[1].Key)
+		}
+		if groups[2].Key["raddr"] != "10.0.0.2" {
+			t.Errorf("expected 10.0.0.2 last, got %v", groups[2].Key)
+		}
+		if groups[2].AvgRttMs != 0 {
+			t.Errorf("expected no rtt average without rtt samples, got %v", groups[2].AvgRttMs)
+		}
+	})
+
+	t.Run("group by numeric field", func(t *testing.T) {
+		groups, err := GroupConnections(conns, []string{"lport"})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(groups) != 2 || groups[0].Key["lport"] != "443" || groups[0].Count != 3 {
+			t.Errorf("expected 3 connections on 443 first, got %+v", groups)
+		}
+	})
+
+	t.Run("unknown field", func(t *testing.T) {
+		if _, err := GroupConnections(conns, []string{"bogus"}); err == nil {
+			t.Error("expected an error for an unknown field")
+		}
+	})
+
+	t.Run("no connections", func(t *testing.T) {
+		groups, err := GroupConnections(nil, []string{"proto"})
+		if err != nil || groups == nil || len(groups) != 0 {
+			t.Errorf("expected an empty list, got %v, %v", groups, err)
+		}
+	})
+}
+
+func TestParseGroupFields(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []string
+		wantErr  bool
+	}{
+		{"raddr,process", []string{"raddr", "process"}, false},
+		{" proto , State ", []string{"proto", "state"}, false},
+		{"lport,", []string{"lport"}, false},
+		{"", nil, true},
+		{"raddr,bogus", nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			fields, err := ParseGroupFields(tt.input)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("expected an error, got %v", fields)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(fields) != len(tt.expected) {
+				t.Fatalf("expected %v, got %v", tt.expected, fields)
+			}
+			for i := range fields {
+				if fields[i] != tt.expected[i] {
+					t.Errorf("expected %v, got %v", tt.expected, fields)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,165 @@
+package collector
+
+import (
+	"fmt"
+	"net"
+	"net/netip"
+	"sort"
+)
+
+// interfaceTable resolves socket addresses to interface names using the
+// addresses assigned to each interface and the routing table
+type interfaceTable struct {
+	addrs    map[netip.Addr]string
+	names    map[int]string
+	routes   []ifaceRoute
+	sorted   bool
+	loopback string
+}
+
+// ifaceRoute is a route entry reduced to what longest-prefix matching needs
+type ifaceRoute struct {
+	prefix netip.Prefix
+	metric int
+	iface  string
+}
+
+func newInterfaceTable() *interfaceTable {
+	return &interfaceTable{
+		addrs:    make(map[netip.Addr]string),
+		names:    make(map[int]string),
+		loopback: "lo",
+	}
+}
+
+// newSystemInterfaceTable builds a table from the interface addresses the
+// OS reports through the net package. it has no routes, so wildcard binds
+// stay unresolved.
+func newSystemInterfaceTable() *interfaceTable {
+	t := newInterfaceTable()
+
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return t
+	}
+
+	for _, iface := range ifaces {
+		t.names[iface.Index] = iface.Name
+
+		addrs, err := iface.Addrs()
+		if err != nil {
+			continue
+		}
+		for _, a := range addrs {
+			ipNet, ok := a.(*net.IPNet)
+			if !ok {
+				continue
+			}
+			if addr, ok := netip.AddrFromSlice(ipNet.IP); ok {
+				t.addAddr(addr, iface.Name)
+			}
+		}
+	}
+
+	return t
+}
+
+// addAddr records that addr is assigned to iface
+func (t *interfaceTable) addAddr(addr netip.Addr, iface string) {
+	addr = addr.Unmap()
+	t.addrs[addr] = iface
+	if addr.IsLoopback() {
+		t.loopback = iface
+	}
+}
+
+// name returns the interface with the given index. index 0 means any
+// interface; indexes the table has no name for are shown as if<index>.
+func (t *interfaceTable) name(index int) string {
+	if index == 0 {
+		return "*"
+	}
+	if name, exists := t.names[index]; exists {
+		return name
+	}
+	return fmt.Sprintf("if%d", index)
+}
+
+// addRoute records a route for longest-prefix matching
+func (t *interfaceTable) addRoute(prefix netip.Prefix, metric int, iface string) {
+	t.routes = append(t.routes, ifaceRoute{prefix: prefix.Masked(), metric: metric, iface: iface})
+	t.sorted = false
+}
+
+// resolve returns the interface for a socket. bound local addresses are
+// looked up in the address list (or the connected route covering them);
+// wildcard binds are routed by their remote address. listeners bound to
+// every address report "*".
+func (t *interfaceTable) resolve(laddr, raddr string) string {
+	if local, ok := parseSocketAddr(laddr); ok {
+		if local.IsLoopback() {
+			return t.loopback
+		}
+		if iface, exists := t.addrs[local]; exists {
+			return iface
+		}
+		return t.route(local)
+	}
+
+	if remote, ok := parseSocketAddr(raddr); ok {
+		if remote.IsLoopback() {
+			return t.loopback
+		}
+		return t.route(remote)
+	}
+
+	if laddr == "*" {
+		return "*"
+	}
+	return ""
+}
+
+// route does a longest-prefix match of addr against the routing table,
+// preferring the lowest metric among equally specific routes
+func (t *interfaceTable) route(addr netip.Addr) string {
+	if !t.sorted {
+		sort.SliceStable(t.routes, func(i, j int) bool {
+			if t.routes[i].prefix.Bits() != t.routes[j].prefix.Bits() {
+				return t.routes[i].prefix.Bits() > t.routes[j].prefix.Bits()
+			}
+			return t.routes[i].metric < t.routes[j].metric
+		})
+		t.sorted = true
+	}
+
+	for _, r := range t.routes {
+		if r.prefix.Addr().Is4() == addr.Is4() && r.prefix.Contains(addr) {
+			return r.iface
+		}
+	}
+	return ""
+}
+
+// assign sets the Interface of every connection that does not already know
+// its device, as packet sockets do
+func (t *interfaceTable) assign(conns []Connection) {
+	for i := range conns {
+		if conns[i].Interface != "" {
+			continue
+		}
+		conns[i].Interface = t.resolve(conns[i].Laddr, conns[i].Raddr)
+	}
+}
+
+// parseSocketAddr parses a connection address, rejecting wildcards and
+// unspecified addresses. ipv4-mapped ipv6 addresses are unmapped.
+func parseSocketAddr(s string) (netip.Addr, bool) {
+	if s == "" || s == "*" {
+		return netip.Addr{}, false
+	}
+	addr, err := netip.ParseAddr(s)
+	if err != nil || addr.IsUnspecified() {
+		return netip.Addr{}, false
+	}
+	return addr.Unmap(), true
+}
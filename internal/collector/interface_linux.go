@@ -0,0 +1,197 @@
+//go:build linux
+
+package collector
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/hex"
+	"math/bits"
+	"net"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// route flags from linux/route.h
+const (
+	rtfUp     = 0x0001
+	rtfReject = 0x0200
+)
+
+// loadInterfaceTable builds the interface table for the namespace whose
+// /proc/<pid>/net view is netDir. /proc only exposes ipv6 addresses, so the
+// namespace snitch runs in also gets its ipv4 addresses over netlink; other
+// namespaces resolve ipv4 locals through their connected routes.
+func loadInterfaceTable(netDir string, self bool) *interfaceTable {
+	t := newInterfaceTable()
+
+	if self {
+		loadNetlinkAddrs(t)
+		if ifaces, err := net.Interfaces(); err == nil {
+			for _, iface := range ifaces {
+				t.names[iface.Index] = iface.Name
+			}
+		}
+	}
+	loadIfInet6(t, filepath.Join(netDir, "if_inet6"))
+	loadIPv4Routes(t, filepath.Join(netDir, "route"))
+	loadIPv6Routes(t, filepath.Join(netDir, "ipv6_route"))
+
+	return t
+}
+
+// loadNetlinkAddrs adds ipv4 interface addresses from an RTM_GETADDR dump
+func loadNetlinkAddrs(t *interfaceTable) {
+	rib, err := syscall.NetlinkRIB(syscall.RTM_GETADDR, syscall.AF_INET)
+	if err != nil {
+		return
+	}
+
+	msgs, err := syscall.ParseNetlinkMessage(rib)
+	if err != nil {
+		return
+	}
+
+	for _, msg := range msgs {
+		if msg.Header.Type != syscall.RTM_NEWADDR {
+			continue
+		}
+
+		attrs, err := syscall.ParseNetlinkRouteAttr(&msg)
+		if err != nil {
+			continue
+		}
+
+		var addr netip.Addr
+		var label string
+		for _, attr := range attrs {
+			switch attr.Attr.Type {
+			case syscall.IFA_LOCAL:
+				addr, _ = netip.AddrFromSlice(attr.Value)
+			case syscall.IFA_ADDRESS:
+				if !addr.IsValid() {
+					addr, _ = netip.AddrFromSlice(attr.Value)
+				}
+			case syscall.IFA_LABEL:
+				label = strings.TrimRight(string(attr.Value), "\x00")
+			}
+		}
+
+		// aliases are labelled eth0:1, the device is eth0
+		if i := strings.IndexByte(label, ':'); i >= 0 {
+			label = label[:i]
+		}
+		if addr.IsValid() && label != "" {
+			t.addAddr(addr, label)
+		}
+	}
+}
+
+// loadIfInet6 adds ipv6 interface addresses from /proc/net/if_inet6
+func loadIfInet6(t *interfaceTable, path string) {
+	file, err := os.Open(path)
+	if err != nil {
+		return
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		// address ifindex prefixlen scope flags name
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 6 {
+			continue
+		}
+
+		raw, err := hex.DecodeString(fields[0])
+		if err != nil || len(raw) != 16 {
+			continue
+		}
+		t.addAddr(netip.AddrFrom16([16]byte(raw)), fields[5])
+
+		if index, err := strconv.ParseInt(fields[1], 16, 32); err == nil {
+			t.names[int(index)] = fields[5]
+		}
+	}
+}
+
+// loadIPv4Routes adds routes from /proc/net/route, whose addresses are
+// little-endian hex
+func loadIPv4Routes(t *interfaceTable, path string) {
+	file, err := os.Open(path)
+	if err != nil {
+		return
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Scan()
+
+	for scanner.Scan() {
+		// Iface Destination Gateway Flags RefCnt Use Metric Mask ...
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 8 {
+			continue
+		}
+
+		flags, err := strconv.ParseUint(fields[3], 16, 32)
+		if err != nil || flags&rtfUp == 0 || flags&rtfReject != 0 {
+			continue
+		}
+
+		dest, err := strconv.ParseUint(fields[1], 16, 32)
+		if err != nil {
+			continue
+		}
+		mask, err := strconv.ParseUint(fields[7], 16, 32)
+		if err != nil {
+			continue
+		}
+		metric, _ := strconv.Atoi(fields[6])
+
+		var destBytes [4]byte
+		binary.LittleEndian.PutUint32(destBytes[:], uint32(dest))
+		prefix := netip.PrefixFrom(netip.AddrFrom4(destBytes), bits.OnesCount32(uint32(mask)))
+
+		t.addRoute(prefix, metric, fields[0])
+	}
+}
+
+// loadIPv6Routes adds routes from /proc/net/ipv6_route
+func loadIPv6Routes(t *interfaceTable, path string) {
+	file, err := os.Open(path)
+	if err != nil {
+		return
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		// dest destlen src srclen nexthop metric refcnt use flags iface
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 10 {
+			continue
+		}
+
+		flags, err := strconv.ParseUint(fields[8], 16, 32)
+		if err != nil || flags&rtfUp == 0 || flags&rtfReject != 0 {
+			continue
+		}
+
+		raw, err := hex.DecodeString(fields[0])
+		if err != nil || len(raw) != 16 {
+			continue
+		}
+		prefixLen, err := strconv.ParseUint(fields[1], 16, 8)
+		if err != nil {
+			continue
+		}
+		metric, _ := strconv.ParseInt(fields[5], 16, 64)
+
+		t.addRoute(netip.PrefixFrom(netip.AddrFrom16([16]byte(raw)), int(prefixLen)), int(metric), fields[9])
+	}
+}
@@ -0,0 +1,52 @@
+//go:build linux
+
+package collector
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadInterfaceTableFromProc(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"route": `Iface	Destination	Gateway 	Flags	RefCnt	Use	Metric	Mask		MTU	Window	IRTT
+eth0	00000000	010200C0	0003	0	0	0	00000000	0	0	0
+eth0	000200C0	00000000	0001	0	0	0	00FFFFFF	0	0	0
+docker0	000011AC	00000000	0001	0	0	0	0000FFFF	0	0	0
+`,
+		"ipv6_route": `fd000000000000000000000000000000 40 00000000000000000000000000000000 00 00000000000000000000000000000000 00000100 00000001 00000000 00000001     eth0
+00000000000000000000000000000000 00 00000000000000000000000000000000 00 00000000000000000000000000000000 ffffffff 00000001 00000000 00200200       lo
+`,
+		"if_inet6": `00000000000000000000000000000001 01 80 10 80       lo
+fd000000000000000000000000000002 04 40 00 82     eth0
+`,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+	}
+
+	table := loadInterfaceTable(dir, false)
+
+	tests := []struct {
+		laddr string
+		raddr string
+		want  string
+	}{
+		{"192.0.2.15", "192.0.2.1", "eth0"},
+		{"172.17.0.1", "172.17.0.2", "docker0"},
+		{"*", "93.184.216.34", "eth0"},
+		{"fd00:0:0:0:0:0:0:2", "*", "eth0"},
+		// the reject route on lo must not swallow unrouted ipv6 traffic
+		{"*", "2001:db8:0:0:0:0:0:1", ""},
+	}
+
+	for _, tt := range tests {
+		if got := table.resolve(tt.laddr, tt.raddr); got != tt.want {
+			t.Errorf("resolve(%q, %q) = %q, want %q", tt.laddr, tt.raddr, got, tt.want)
+		}
+	}
+}
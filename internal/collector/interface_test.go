@@ -0,0 +1,44 @@
+package collector
+
+import (
+	"net/netip"
+	"testing"
+)
+
+func TestInterfaceTableResolve(t *testing.T) {
+	table := newInterfaceTable()
+	table.addAddr(netip.MustParseAddr("127.0.0.1"), "lo")
+	table.addAddr(netip.MustParseAddr("192.168.1.10"), "eth0")
+	table.addAddr(netip.MustParseAddr("10.8.0.2"), "wg0")
+	table.addRoute(netip.MustParsePrefix("0.0.0.0/0"), 100, "eth0")
+	table.addRoute(netip.MustParsePrefix("0.0.0.0/0"), 50, "eth1")
+	table.addRoute(netip.MustParsePrefix("10.8.0.0/16"), 0, "wg0")
+	table.addRoute(netip.MustParsePrefix("172.17.0.0/16"), 0, "docker0")
+	table.addRoute(netip.MustParsePrefix("fd00::/64"), 256, "eth0")
+
+	tests := []struct {
+		name  string
+		laddr string
+		raddr string
+		want  string
+	}{
+		{"bound address", "192.168.1.10", "203.0.113.5", "eth0"},
+		{"bound address on tunnel", "10.8.0.2", "10.8.3.4", "wg0"},
+		{"loopback outside address list", "127.0.0.53", "*", "lo"},
+		{"local covered by connected route", "172.17.0.1", "172.17.0.5", "docker0"},
+		{"wildcard routed by remote", "*", "10.8.1.1", "wg0"},
+		{"wildcard default route picks lowest metric", "*", "8.8.8.8", "eth1"},
+		{"ipv4-mapped remote", "*", "0:0:0:0:0:ffff:a08:101", "wg0"},
+		{"ipv6 route", "*", "fd00:0:0:0:0:0:0:5", "eth0"},
+		{"wildcard listener", "*", "*", "*"},
+		{"unix path", "/run/app.sock", "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := table.resolve(tt.laddr, tt.raddr); got != tt.want {
+				t.Errorf("resolve(%q, %q) = %q, want %q", tt.laddr, tt.raddr, got, tt.want)
+			}
+		})
+	}
+}
@@ -36,11 +36,26 @@ func NewMockCollectorFromFile(filename string) (*MockCollector, error) {
 	}, nil
 }
 
-// GetConnections returns the mock connections
+// GetConnections returns the mock network connections
 func (m *MockCollector) GetConnections() ([]Connection, error) {
 	// Return a copy to avoid mutation
-	result := make([]Connection, len(m.connections))
-	copy(result, m.connections)
+	result := make([]Connection, 0, len(m.connections))
+	for _, c := range m.connections {
+		if c.Proto != "unix" {
+			result = append(result, c)
+		}
+	}
+	return result, nil
+}
+
+// GetUnixSockets returns the mock unix domain sockets
+func (m *MockCollector) GetUnixSockets() ([]Connection, error) {
+	result := make([]Connection, 0)
+	for _, c := range m.connections {
+		if c.Proto == "unix" {
+			result = append(result, c)
+		}
+	}
 	return result, nil
 }
 
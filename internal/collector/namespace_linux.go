@@ -0,0 +1,124 @@
+//go:build linux
+
+package collector
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// netnsRunDir is where `ip netns add` bind-mounts named namespaces
+const netnsRunDir = "/run/netns"
+
+// netNamespace is a distinct network namespace and one pid living in it,
+// whose /proc/<pid>/net tables show that namespace's sockets
+type netNamespace struct {
+	inode int64
+	name  string
+	pid   int
+	self  bool
+}
+
+// listNetNamespaces finds every network namespace reachable through
+// /proc/*/ns/net. the namespace snitch runs in is always first.
+func listNetNamespaces() ([]netNamespace, error) {
+	selfInode, err := readNetNSInode(procfs.path("self", "ns", "net"))
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := procfs.readDir()
+	if err != nil {
+		return nil, err
+	}
+
+	names := namedNetNamespaces()
+	if initInode, err := readNetNSInode(procfs.pidPath(1, "ns", "net")); err == nil {
+		if _, named := names[initInode]; !named {
+			names[initInode] = "init"
+		}
+	}
+
+	seen := make(map[int64]*netNamespace)
+	for _, entry := range entries {
+		pid, err := strconv.Atoi(entry.Name())
+		if err != nil {
+			continue
+		}
+
+		inode, err := readNetNSInode(procfs.pidPath(pid, "ns", "net"))
+		if err != nil {
+			continue
+		}
+
+		if _, exists := seen[inode]; exists {
+			continue
+		}
+		seen[inode] = &netNamespace{
+			inode: inode,
+			name:  names[inode],
+			pid:   pid,
+			self:  inode == selfInode,
+		}
+	}
+
+	namespaces := make([]netNamespace, 0, len(seen))
+	for _, ns := range seen {
+		namespaces = append(namespaces, *ns)
+	}
+	sort.Slice(namespaces, func(i, j int) bool {
+		if namespaces[i].self != namespaces[j].self {
+			return namespaces[i].self
+		}
+		return namespaces[i].inode < namespaces[j].inode
+	})
+
+	return namespaces, nil
+}
+
+// readNetNSInode parses the "net:[4026531840]" link target of an ns file
+func readNetNSInode(path string) (int64, error) {
+	link, err := os.Readlink(path)
+	if err != nil {
+		return 0, err
+	}
+
+	if !strings.HasPrefix(link, "net:[") || !strings.HasSuffix(link, "]") {
+		return 0, fmt.Errorf("unexpected namespace link: %s", link)
+	}
+
+	return strconv.ParseInt(link[5:len(link)-1], 10, 64)
+}
+
+// namedNetNamespaces maps namespace inodes to the names given by `ip netns`
+func namedNetNamespaces() map[int64]string {
+	names := make(map[int64]string)
+
+	entries, err := os.ReadDir(netnsRunDir)
+	if err != nil {
+		return names
+	}
+
+	for _, entry := range entries {
+		var st syscall.Stat_t
+		if err := syscall.Stat(filepath.Join(netnsRunDir, entry.Name()), &st); err != nil {
+			continue
+		}
+		names[int64(st.Ino)] = entry.Name()
+	}
+
+	return names
+}
+
+// tag marks every connection as belonging to this namespace
+func (ns netNamespace) tag(conns []Connection) {
+	for i := range conns {
+		conns[i].NamespaceInode = ns.inode
+		conns[i].Namespace = ns.name
+	}
+}
@@ -0,0 +1,54 @@
+//go:build linux
+
+package collector
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestReadNetNSInode(t *testing.T) {
+	dir := t.TempDir()
+
+	good := filepath.Join(dir, "good")
+	if err := os.Symlink("net:[4026531840]", good); err != nil {
+		t.Fatalf("failed to create symlink: %v", err)
+	}
+	inode, err := readNetNSInode(good)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if inode != 4026531840 {
+		t.Errorf("expected inode 4026531840, got %d", inode)
+	}
+
+	bad := filepath.Join(dir, "bad")
+	if err := os.Symlink("mnt:[4026531841]", bad); err != nil {
+		t.Fatalf("failed to create symlink: %v", err)
+	}
+	if _, err := readNetNSInode(bad); err == nil {
+		t.Error("expected error for non-net namespace link")
+	}
+}
+
+func TestListNetNamespacesSelfFirst(t *testing.T) {
+	namespaces, err := listNetNamespaces()
+	if err != nil {
+		t.Skipf("namespaces not readable here: %v", err)
+	}
+	if len(namespaces) == 0 {
+		t.Fatal("expected at least the current namespace")
+	}
+	if !namespaces[0].self {
+		t.Error("expected the current namespace to be listed first")
+	}
+
+	seen := make(map[int64]bool)
+	for _, ns := range namespaces {
+		if seen[ns.inode] {
+			t.Errorf("namespace %d listed twice", ns.inode)
+		}
+		seen[ns.inode] = true
+	}
+}
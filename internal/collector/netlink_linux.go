@@ -0,0 +1,342 @@
+//go:build linux
+
+package collector
+
+import (
+	"encoding/binary"
+	"fmt"
+	"os"
+	"syscall"
+	"time"
+)
+
+// sock_diag constants from linux/sock_diag.h and linux/inet_diag.h
+const (
+	netlinkSockDiag   = 4  // NETLINK_SOCK_DIAG
+	sockDiagByFamily  = 20 // SOCK_DIAG_BY_FAMILY
+	inetDiagInfo      = 2  // INET_DIAG_INFO
+	inetDiagMark      = 15 // INET_DIAG_MARK
+	inetDiagReqV2Len  = 56 // sizeof(struct inet_diag_req_v2)
+	inetDiagMsgLen    = 72 // sizeof(struct inet_diag_msg)
+	inetDiagAllStates = 0xffffffff
+)
+
+// offsets into struct tcp_info from linux/tcp.h
+const (
+	tcpInfoRttOffset           = 68
+	tcpInfoSndCwndOffset       = 80
+	tcpInfoBytesAckedOffset    = 120
+	tcpInfoBytesReceivedOffset = 128
+)
+
+// NetlinkCollector implements the Collector interface using NETLINK_SOCK_DIAG.
+// it asks the kernel for socket tables directly instead of parsing /proc/net
+// text and fills RttMs, RxBytes, TxBytes, Cwnd and Mark from tcp_info.
+type NetlinkCollector struct {
+	// Fallback is used when sock_diag is unavailable (e.g. the inet_diag
+	// module is not loaded). nil means errors are returned instead.
+	Fallback Collector
+
+	diag lastDiagnostics
+}
+
+// diagTable describes one family/protocol pair to dump over sock_diag
+type diagTable struct {
+	proto     string
+	family    uint8
+	protocol  uint8
+	ipVersion int
+}
+
+var diagTables = []diagTable{
+	{"tcp", syscall.AF_INET, syscall.IPPROTO_TCP, 4},
+	{"tcp6", syscall.AF_INET6, syscall.IPPROTO_TCP, 6},
+	{"udp", syscall.AF_INET, syscall.IPPROTO_UDP, 4},
+	{"udp6", syscall.AF_INET6, syscall.IPPROTO_UDP, 6},
+}
+
+// GetConnections fetches all network connections over NETLINK_SOCK_DIAG
+func (nc *NetlinkCollector) GetConnections() ([]Connection, error) {
+	report := newDiagnostics()
+	connections, err := nc.dumpAll(report)
+	if err != nil {
+		if nc.Fallback != nil {
+			connections, fallbackErr := nc.Fallback.GetConnections()
+			fallback := Diagnostics{}
+			if dr, ok := nc.Fallback.(DiagnosticsReporter); ok {
+				fallback = dr.Diagnostics()
+			}
+			fallback.Fallback = err.Error()
+			nc.diag.set(fallback)
+			return connections, fallbackErr
+		}
+		return nil, err
+	}
+	nc.diag.set(*report)
+	return connections, nil
+}
+
+// Diagnostics reports what the last collection could not see
+func (nc *NetlinkCollector) Diagnostics() Diagnostics {
+	return nc.diag.get()
+}
+
+func (nc *NetlinkCollector) dumpAll(report *Diagnostics) ([]Connection, error) {
+	fd, err := openSockDiag()
+	if err != nil {
+		return nil, err
+	}
+	defer syscall.Close(fd)
+
+	inodeMap, err := buildInodeToProcessMap(report)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build inode map: %w", err)
+	}
+
+	var connections []Connection
+	for seq, table := range diagTables {
+		conns, err := dumpDiagTable(fd, uint32(seq+1), table, inodeMap)
+		if err != nil {
+			return nil, fmt.Errorf("sock_diag %s dump failed: %w", table.proto, err)
+		}
+		connections = append(connections, conns...)
+	}
+
+	ifaces := loadInterfaceTable(procfs.selfNetDir(), true)
+	connections = append(connections, parseProcExtraTables(procfs.selfNetDir(), inodeMap, ifaces, report)...)
+	ifaces.assign(connections)
+
+	// sock_diag only sees the namespace it was opened in, so other
+	// namespaces are read from their /proc/<pid>/net tables instead
+
+	namespaces, err := listNetNamespaces()
+	if err != nil {
+		processes.resolve(connections, inodeMap)
+		return connections, nil
+	}
+	for _, ns := range namespaces {
+		if ns.self {
+			ns.tag(connections)
+			continue
+		}
+		netDir := procfs.netDir(ns.pid)
+		nsIfaces := loadInterfaceTable(netDir, false)
+		conns := parseProcNetTables(netDir, inodeMap, nsIfaces, report)
+		ns.tag(conns)
+		nsIfaces.assign(conns)
+		connections = append(connections, conns...)
+	}
+	processes.resolve(connections, inodeMap)
+
+	return connections, nil
+}
+
+func dumpDiagTable(fd int, seq uint32, table diagTable, inodeMap map[int64][]socketOwner) ([]Connection, error) {
+	req := buildInetDiagRequest(seq, table.family, table.protocol, 1<<(inetDiagInfo-1))
+
+	var connections []Connection
+	err := sockDiagDump(fd, seq, req, func(data []byte) {
+		conn, err := parseInetDiagMsg(data, table.proto, table.ipVersion)
+		if err != nil {
+			return
+		}
+		attachProcess(&conn, inodeMap)
+		connections = append(connections, conn)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return connections, nil
+}
+
+// sockDiagDump sends a dump request and passes the payload of every reply
+// message to handle until the kernel signals the end of the dump
+func sockDiagDump(fd int, seq uint32, req []byte, handle func(data []byte)) error {
+	if err := syscall.Sendto(fd, req, 0, &syscall.SockaddrNetlink{Family: syscall.AF_NETLINK}); err != nil {
+		return err
+	}
+
+	buf := make([]byte, os.Getpagesize()*8)
+
+	for {
+		n, _, err := syscall.Recvfrom(fd, buf, 0)
+		if err != nil {
+			return err
+		}
+
+		msgs, err := syscall.ParseNetlinkMessage(buf[:n])
+		if err != nil {
+			return err
+		}
+
+		for _, msg := range msgs {
+			if msg.Header.Seq != seq {
+				continue
+			}
+
+			switch msg.Header.Type {
+			case syscall.NLMSG_DONE:
+				return nil
+			case syscall.NLMSG_ERROR:
+				if len(msg.Data) >= 4 {
+					if errno := int32(binary.NativeEndian.Uint32(msg.Data[:4])); errno != 0 {
+						return syscall.Errno(-errno)
+					}
+				}
+				return nil
+			}
+
+			handle(msg.Data)
+		}
+	}
+}
+
+// openSockDiag opens and binds a NETLINK_SOCK_DIAG socket
+func openSockDiag() (int, error) {
+	fd, err := syscall.Socket(syscall.AF_NETLINK, syscall.SOCK_RAW|syscall.SOCK_CLOEXEC, netlinkSockDiag)
+	if err != nil {
+		return -1, fmt.Errorf("failed to open sock_diag socket: %w", err)
+	}
+
+	if err := syscall.Bind(fd, &syscall.SockaddrNetlink{Family: syscall.AF_NETLINK}); err != nil {
+		syscall.Close(fd)
+		return -1, fmt.Errorf("failed to bind sock_diag socket: %w", err)
+	}
+
+	return fd, nil
+}
+
+// buildInetDiagRequest encodes an nlmsghdr followed by struct inet_diag_req_v2
+// requesting a dump of every socket of the given family and protocol
+func buildInetDiagRequest(seq uint32, family, protocol, ext uint8) []byte {
+	buf := make([]byte, syscall.NLMSG_HDRLEN+inetDiagReqV2Len)
+
+	binary.NativeEndian.PutUint32(buf[0:4], uint32(len(buf)))
+	binary.NativeEndian.PutUint16(buf[4:6], sockDiagByFamily)
+	binary.NativeEndian.PutUint16(buf[6:8], syscall.NLM_F_REQUEST|syscall.NLM_F_DUMP)
+	binary.NativeEndian.PutUint32(buf[8:12], seq)
+
+	req := buf[syscall.NLMSG_HDRLEN:]
+	req[0] = family
+	req[1] = protocol
+	req[2] = ext
+	binary.NativeEndian.PutUint32(req[4:8], inetDiagAllStates)
+
+	return buf
+}
+
+// parseInetDiagMsg decodes a struct inet_diag_msg and its trailing attributes
+func parseInetDiagMsg(data []byte, proto string, ipVersion int) (Connection, error) {
+	if len(data) < inetDiagMsgLen {
+		return Connection{}, fmt.Errorf("short inet_diag_msg: %d bytes", len(data))
+	}
+
+	family := data[0]
+	localPort := int(binary.BigEndian.Uint16(data[4:6]))
+	remotePort := int(binary.BigEndian.Uint16(data[6:8]))
+	localAddr := formatDiagAddr(family, data[8:24])
+	remoteAddr := formatDiagAddr(family, data[24:40])
+	recvQ := binary.NativeEndian.Uint32(data[56:60])
+	sendQ := binary.NativeEndian.Uint32(data[60:64])
+	uid := binary.NativeEndian.Uint32(data[64:68])
+	inode := binary.NativeEndian.Uint32(data[68:72])
+
+	state := refineUDPState(stateName(int64(data[1]), proto), proto, remoteAddr, remotePort)
+
+	conn := Connection{
+		TS:        time.Now(),
+		Proto:     proto,
+		IPVersion: fmt.Sprintf("IPv%d", ipVersion),
+		State:     state,
+		Laddr:     localAddr,
+		Lport:     localPort,
+		Raddr:     remoteAddr,
+		Rport:     remotePort,
+		Inode:     int64(inode),
+
+		SendQ:       int(sendQ),
+		RecvQ:       int(recvQ),
+		Retransmits: int(data[3]),
+		Timer:       timerName(int(data[2])),
+	}
+	setSocketUID(&conn, int(uid))
+
+	forEachDiagAttr(data[inetDiagMsgLen:], func(attrType uint16, payload []byte) {
+		switch attrType {
+		case inetDiagInfo:
+			applyTCPInfo(&conn, payload)
+		case inetDiagMark:
+			if len(payload) >= 4 {
+				conn.Mark = fmt.Sprintf("0x%x", binary.NativeEndian.Uint32(payload[:4]))
+			}
+		}
+	})
+
+	return conn, nil
+}
+
+// forEachDiagAttr walks the rtattr list trailing a sock_diag message
+func forEachDiagAttr(attrs []byte, fn func(attrType uint16, payload []byte)) {
+	for len(attrs) >= syscall.SizeofRtAttr {
+		attrLen := int(binary.NativeEndian.Uint16(attrs[0:2]))
+		attrType := binary.NativeEndian.Uint16(attrs[2:4])
+		if attrLen < syscall.SizeofRtAttr || attrLen > len(attrs) {
+			break
+		}
+		fn(attrType, attrs[syscall.SizeofRtAttr:attrLen])
+
+		aligned := (attrLen + syscall.RTA_ALIGNTO - 1) &^ (syscall.RTA_ALIGNTO - 1)
+		if aligned > len(attrs) {
+			break
+		}
+		attrs = attrs[aligned:]
+	}
+}
+
+// applyTCPInfo copies the fields snitch cares about out of a struct tcp_info.
+// older kernels send a shorter struct, so every read is bounds-checked.
+func applyTCPInfo(conn *Connection, info []byte) {
+	if len(info) >= tcpInfoRttOffset+4 {
+		rttUsec := binary.NativeEndian.Uint32(info[tcpInfoRttOffset : tcpInfoRttOffset+4])
+		conn.RttMs = float64(rttUsec) / 1000
+	}
+	if len(info) >= tcpInfoSndCwndOffset+4 {
+		conn.Cwnd = int(binary.NativeEndian.Uint32(info[tcpInfoSndCwndOffset : tcpInfoSndCwndOffset+4]))
+	}
+	if len(info) >= tcpInfoBytesAckedOffset+8 {
+		conn.TxBytes = int64(binary.NativeEndian.Uint64(info[tcpInfoBytesAckedOffset : tcpInfoBytesAckedOffset+8]))
+	}
+	if len(info) >= tcpInfoBytesReceivedOffset+8 {
+		conn.RxBytes = int64(binary.NativeEndian.Uint64(info[tcpInfoBytesReceivedOffset : tcpInfoBytesReceivedOffset+8]))
+	}
+}
+
+// formatDiagAddr renders a network-order address the same way parseHexAddr
+// renders /proc/net entries, so both backends produce comparable output
+func formatDiagAddr(family uint8, raw []byte) string {
+	if family == syscall.AF_INET {
+		addr := fmt.Sprintf("%d.%d.%d.%d", raw[0], raw[1], raw[2], raw[3])
+		if addr == "0.0.0.0" {
+			return "*"
+		}
+		return addr
+	}
+
+	var groups [8]int64
+	allZero := true
+	for i := range groups {
+		groups[i] = int64(binary.BigEndian.Uint16(raw[i*2 : i*2+2]))
+		if groups[i] != 0 {
+			allZero = false
+		}
+	}
+	if allZero {
+		return "*"
+	}
+
+	addr := formatHex(groups[0])
+	for _, g := range groups[1:] {
+		addr += ":" + formatHex(g)
+	}
+	return addr
+}
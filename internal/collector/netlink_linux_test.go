@@ -0,0 +1,161 @@
+//go:build linux
+
+package collector
+
+import (
+	"encoding/binary"
+	"syscall"
+	"testing"
+)
+
+// buildDiagMsg assembles an inet_diag_msg followed by the given attributes
+func buildDiagMsg(family, state uint8, src, dst []byte, sport, dport uint16, inode uint32, attrs map[uint16][]byte) []byte {
+	msg := make([]byte, inetDiagMsgLen)
+	msg[0] = family
+	msg[1] = state
+	binary.BigEndian.PutUint16(msg[4:6], sport)
+	binary.BigEndian.PutUint16(msg[6:8], dport)
+	copy(msg[8:24], src)
+	copy(msg[24:40], dst)
+	binary.NativeEndian.PutUint32(msg[68:72], inode)
+
+	for typ, payload := range attrs {
+		attr := make([]byte, (syscall.SizeofRtAttr+len(payload)+3)&^3)
+		binary.NativeEndian.PutUint16(attr[0:2], uint16(syscall.SizeofRtAttr+len(payload)))
+		binary.NativeEndian.PutUint16(attr[2:4], typ)
+		copy(attr[syscall.SizeofRtAttr:], payload)
+		msg = append(msg, attr...)
+	}
+	return msg
+}
+
+func TestParseInetDiagMsg(t *testing.T) {
+	t.Run("tcp established with tcp_info and mark", func(t *testing.T) {
+		info := make([]byte, tcpInfoBytesReceivedOffset+8)
+		binary.NativeEndian.PutUint32(info[tcpInfoRttOffset:], 1500)
+		binary.NativeEndian.PutUint32(info[tcpInfoSndCwndOffset:], 10)
+		binary.NativeEndian.PutUint64(info[tcpInfoBytesAckedOffset:], 2048)
+		binary.NativeEndian.PutUint64(info[tcpInfoBytesReceivedOffset:], 4096)
+		mark := make([]byte, 4)
+		binary.NativeEndian.PutUint32(mark, 0x2a)
+
+		data := buildDiagMsg(syscall.AF_INET, 0x01,
+			[]byte{10, 0, 0, 1}, []byte{203, 0, 113, 10}, 443, 52344, 12345,
+			map[uint16][]byte{inetDiagInfo: info, inetDiagMark: mark})
+
+		binary.NativeEndian.PutUint32(data[64:68], 4242424)
+
+		conn, err := parseInetDiagMsg(data, "tcp", 4)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if conn.UID != 4242424 || conn.User != "4242424" || conn.UIDSource != UIDSourceSocket {
+			t.Errorf("expected uid 4242424 from the socket, got %d (%q) from %q", conn.UID, conn.User, conn.UIDSource)
+		}
+
+		if conn.Laddr != "10.0.0.1" || conn.Lport != 443 {
+			t.Errorf("expected local 10.0.0.1:443, got %s:%d", conn.Laddr, conn.Lport)
+		}
+		if conn.Raddr != "203.0.113.10" || conn.Rport != 52344 {
+			t.Errorf("expected remote 203.0.113.10:52344, got %s:%d", conn.Raddr, conn.Rport)
+		}
+		if conn.State != "ESTABLISHED" {
+			t.Errorf("expected ESTABLISHED, got %s", conn.State)
+		}
+		if conn.Inode != 12345 {
+			t.Errorf("expected inode 12345, got %d", conn.Inode)
+		}
+		if conn.RttMs != 1.5 {
+			t.Errorf("expected rtt 1.5ms, got %v", conn.RttMs)
+		}
+		if conn.TxBytes != 2048 || conn.RxBytes != 4096 {
+			t.Errorf("expected tx=2048 rx=4096, got tx=%d rx=%d", conn.TxBytes, conn.RxBytes)
+		}
+		if conn.Cwnd != 10 {
+			t.Errorf("expected cwnd 10, got %d", conn.Cwnd)
+		}
+		if conn.Mark != "0x2a" {
+			t.Errorf("expected mark 0x2a, got %s", conn.Mark)
+		}
+	})
+
+	t.Run("short tcp_info is tolerated", func(t *testing.T) {
+		info := make([]byte, tcpInfoRttOffset+4)
+		binary.NativeEndian.PutUint32(info[tcpInfoRttOffset:], 250)
+
+		data := buildDiagMsg(syscall.AF_INET, 0x01,
+			[]byte{127, 0, 0, 1}, []byte{127, 0, 0, 1}, 5432, 45678, 1,
+			map[uint16][]byte{inetDiagInfo: info})
+
+		conn, err := parseInetDiagMsg(data, "tcp", 4)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if conn.RttMs != 0.25 {
+			t.Errorf("expected rtt 0.25ms, got %v", conn.RttMs)
+		}
+		if conn.RxBytes != 0 || conn.TxBytes != 0 {
+			t.Errorf("expected zero byte counters, got rx=%d tx=%d", conn.RxBytes, conn.TxBytes)
+		}
+	})
+
+	t.Run("udp6 wildcard is listening", func(t *testing.T) {
+		data := buildDiagMsg(syscall.AF_INET6, 0x07,
+			make([]byte, 16), make([]byte, 16), 53, 0, 7, nil)
+
+		conn, err := parseInetDiagMsg(data, "udp6", 6)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if conn.Laddr != "*" || conn.Raddr != "*" {
+			t.Errorf("expected wildcard addresses, got %s and %s", conn.Laddr, conn.Raddr)
+		}
+		if conn.State != "LISTEN" {
+			t.Errorf("expected LISTEN, got %s", conn.State)
+		}
+		if conn.IPVersion != "IPv6" {
+			t.Errorf("expected IPv6, got %s", conn.IPVersion)
+		}
+	})
+
+	t.Run("short message is rejected", func(t *testing.T) {
+		if _, err := parseInetDiagMsg(make([]byte, 10), "tcp", 4); err == nil {
+			t.Error("expected error for truncated message")
+		}
+	})
+}
+
+func TestFormatDiagAddrMatchesProc(t *testing.T) {
+	// ::1 as it appears in /proc/net/tcp6
+	procAddr, _, err := parseHexAddr("00000000000000000000000001000000:0050")
+	if err != nil {
+		t.Fatalf("parseHexAddr failed: %v", err)
+	}
+
+	raw := make([]byte, 16)
+	raw[15] = 1
+	if got := formatDiagAddr(syscall.AF_INET6, raw); got != procAddr {
+		t.Errorf("expected %q to match /proc rendering %q", got, procAddr)
+	}
+}
+
+func TestNewCollector(t *testing.T) {
+	tests := []struct {
+		backend string
+		wantErr bool
+	}{
+		{"", false},
+		{"auto", false},
+		{"netlink", false},
+		{"proc", false},
+		{"bogus", true},
+	}
+
+	for _, tt := range tests {
+		_, err := NewCollector(tt.backend)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("NewCollector(%q) error = %v, wantErr %v", tt.backend, err, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,381 @@
+//go:build linux
+
+package collector
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// processes caches socket ownership between collections, so top, watch and
+// trace only pay for processes and fds that changed since the last tick
+var processes = newProcessCache(procfs, getProcessInfo)
+
+// processCache maps socket inodes to their owning processes incrementally.
+// a process is identified by its pid, command and start time; its metadata
+// is loaded once and only fds that were not open on the previous scan are
+// read again.
+type processCache struct {
+	mu    sync.Mutex
+	fs    procFS
+	load  func(pid int) (*processInfo, error)
+	procs map[int]*cachedProcess
+
+	// unowned holds socket inodes no process was found for, such as kernel
+	// sockets, with the refresh they were last missed in, so they do not
+	// trigger a rescan on every collection
+	unowned   map[int64]int
+	refreshes int
+
+	// processes seen by the last refresh and how many had unreadable fds
+	scanned int
+	denied  int
+}
+
+// unownedTTL is how many refreshes an unowned inode is remembered after it
+// was last missed. network and unix sockets are collected with separate
+// refreshes, so it has to cover more than one.
+const unownedTTL = 4
+
+// cachedProcess is what the cache remembers about one process
+type cachedProcess struct {
+	info     *processInfo
+	identity string
+	fds      map[string]int64 // fd number -> socket inode, 0 for other files
+
+	// activity is the fd table size and the context switch counts of its
+	// threads the fds were scanned at, denied whether the fd directory could
+	// not be read
+	activity string
+	denied   bool
+}
+
+func newProcessCache(fs procFS, load func(pid int) (*processInfo, error)) *processCache {
+	return &processCache{
+		fs:      fs,
+		load:    load,
+		procs:   make(map[int]*cachedProcess),
+		unowned: make(map[int64]int),
+	}
+}
+
+// buildInodeToProcessMap refreshes the process cache, recording how many
+// processes could not be inspected in report
+func buildInodeToProcessMap(report *Diagnostics) (map[int64][]socketOwner, error) {
+	inodeMap, err := processes.inodeMap()
+	if err != nil {
+		return nil, err
+	}
+	report.Processes, report.ProcessesDenied = processes.lastScan()
+	return inodeMap, nil
+}
+
+// inodeMap refreshes the cache and returns the owner of every socket inode.
+// new processes and processes whose pid was reused are scanned in full,
+// exited ones are dropped, and the fd directory of the rest is only listed
+// when the process ran since the last refresh, then resolving just the fds
+// that appeared. processes whose fds are not readable stay cached without
+// sockets, so they are not loaded again on every refresh.
+func (pc *processCache) inodeMap() (map[int64][]socketOwner, error) {
+	entries, err := pc.fs.readDir()
+	if err != nil {
+		return nil, err
+	}
+
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+
+	pc.refreshes++
+	for inode, last := range pc.unowned {
+		if pc.refreshes-last > unownedTTL {
+			delete(pc.unowned, inode)
+		}
+	}
+
+	pc.scanned, pc.denied = 0, 0
+	seen := make(map[int]bool, len(pc.procs))
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		pid, err := strconv.Atoi(entry.Name())
+		if err != nil {
+			continue
+		}
+
+		// a process none of whose threads were scheduled since the last
+		// refresh cannot have exec'd, opened or closed fds, so neither its
+		// stat nor its fd directory are read again
+		activity := pc.activity(pid)
+		if cached, exists := pc.procs[pid]; exists && activity != "" && activity == cached.activity {
+			pc.markSeen(seen, pid, cached)
+			continue
+		}
+
+		identity, ok := pc.identity(pid)
+		if !ok {
+			continue
+		}
+
+		cached, exists := pc.procs[pid]
+		if !exists || cached.identity != identity {
+			info, err := pc.load(pid)
+			if err != nil {
+				continue
+			}
+			cached = &cachedProcess{info: info, identity: identity}
+			pc.procs[pid] = cached
+		}
+
+		err = pc.scanFds(pid, cached, false)
+		if err != nil && !errors.Is(err, fs.ErrPermission) {
+			continue
+		}
+		cached.activity = activity
+		cached.denied = err != nil
+		pc.markSeen(seen, pid, cached)
+	}
+
+	for pid := range pc.procs {
+		if !seen[pid] {
+			delete(pc.procs, pid)
+		}
+	}
+
+	return pc.buildMap(), nil
+}
+
+// resolve attaches owners to connections whose inode was not in inodeMap.
+// an fd number that was closed and reused for a new socket between two
+// refreshes looks unchanged in the fd listing, so unknown inodes force every
+// cached fd to be read again once. inodes that still have no owner are
+// remembered as unowned for a few refreshes.
+func (pc *processCache) resolve(conns []Connection, inodeMap map[int64][]socketOwner) {
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+
+	missing := make(map[int64]bool)
+	rescan := false
+	for _, conn := range conns {
+		if conn.Inode == 0 || conn.PID != 0 {
+			continue
+		}
+		if _, exists := inodeMap[conn.Inode]; exists {
+			continue
+		}
+		missing[conn.Inode] = true
+		if _, known := pc.unowned[conn.Inode]; !known {
+			rescan = true
+		}
+	}
+
+	if rescan {
+		for pid, cached := range pc.procs {
+			err := pc.scanFds(pid, cached, true)
+			if err != nil && !errors.Is(err, fs.ErrPermission) {
+				delete(pc.procs, pid)
+			}
+		}
+		for inode, owners := range pc.buildMap() {
+			inodeMap[inode] = owners
+		}
+		for i := range conns {
+			if conns[i].PID == 0 && missing[conns[i].Inode] {
+				attachProcess(&conns[i], inodeMap)
+			}
+		}
+	}
+
+	for inode := range missing {
+		if _, exists := inodeMap[inode]; !exists {
+			pc.unowned[inode] = pc.refreshes
+		}
+	}
+}
+
+// identity returns the pid and command prefix of /proc/<pid>/stat together
+// with the start time, which changes when an exec renames the process or the
+// pid is reused
+func (pc *processCache) identity(pid int) (string, bool) {
+	data, err := pc.fs.readPidFile(pid, "stat")
+	if err != nil {
+		return "", false
+	}
+
+	stat := string(data)
+	start, ok := parseStatStartTime(stat)
+	if !ok {
+		return "", false
+	}
+	return stat[:strings.LastIndexByte(stat, ')')+1] + strconv.FormatInt(start, 10), true
+}
+
+func (pc *processCache) markSeen(seen map[int]bool, pid int, cached *cachedProcess) {
+	seen[pid] = true
+	pc.scanned++
+	if cached.denied {
+		pc.denied++
+	}
+}
+
+// activity returns the name, parent and FDSize of /proc/<pid>/status with
+// the context switch counts of every thread in /proc/<pid>/task. sockets
+// are often opened by worker threads while the leader sleeps, so the counts
+// of the leader alone are not enough. it is empty when either cannot be
+// read.
+func (pc *processCache) activity(pid int) string {
+	status, err := pc.fs.readPidFile(pid, "status")
+	if err != nil {
+		return ""
+	}
+
+	var b strings.Builder
+	writeStatusLines(&b, status, "Name", "PPid", "FDSize")
+
+	leader := strconv.Itoa(pid)
+	tasks, err := pc.fs.readDir(leader, "task")
+	if err != nil {
+		return ""
+	}
+	for _, task := range tasks {
+		// the counts in the status of the process are the leader's own
+		data := status
+		if task.Name() != leader {
+			data, err = pc.fs.readPidFile(pid, "task", task.Name(), "status")
+			if err != nil {
+				// the thread exited while listing, which is activity too
+				return ""
+			}
+		}
+		b.WriteString(task.Name())
+		b.WriteByte(':')
+		writeStatusLines(&b, data, "voluntary_ctxt_switches", "nonvoluntary_ctxt_switches")
+	}
+	return b.String()
+}
+
+// writeStatusLines appends the lines of a status file with one of keys to
+// b, with their whitespace collapsed
+func writeStatusLines(b *strings.Builder, data []byte, keys ...string) {
+	for _, line := range strings.Split(string(data), "\n") {
+		key, _, _ := strings.Cut(line, ":")
+		for _, k := range keys {
+			if key == k {
+				b.WriteString(strings.Join(strings.Fields(line), " "))
+				b.WriteByte(';')
+				break
+			}
+		}
+	}
+}
+
+// scanFds updates the fd table of a process, reading the link of every fd
+// when full is set and only of new fds otherwise. it fails when the fd
+// directory cannot be read, because the process exited or belongs to
+// another user.
+func (pc *processCache) scanFds(pid int, cached *cachedProcess, full bool) error {
+	fdDir := pc.fs.pidPath(pid, "fd")
+	fdEntries, err := os.ReadDir(fdDir)
+	if err != nil {
+		return err
+	}
+
+	fds := make(map[string]int64, len(fdEntries))
+	for _, fdEntry := range fdEntries {
+		name := fdEntry.Name()
+		if inode, exists := cached.fds[name]; exists && !full {
+			fds[name] = inode
+			continue
+		}
+
+		link, err := os.Readlink(filepath.Join(fdDir, name))
+		if err != nil {
+			continue
+		}
+		fds[name] = parseSocketLink(link)
+	}
+	cached.fds = fds
+
+	return nil
+}
+
+// lastScan returns how many processes the last refresh saw and how many of
+// them had unreadable fds
+func (pc *processCache) lastScan() (int, int) {
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+	return pc.scanned, pc.denied
+}
+
+// buildMap groups the cached fds by socket inode. owners are sorted by pid
+// and their fds in ascending order.
+func (pc *processCache) buildMap() map[int64][]socketOwner {
+	inodeMap := make(map[int64][]socketOwner)
+	for _, cached := range pc.procs {
+		held := make(map[int64][]int)
+		for name, inode := range cached.fds {
+			if inode == 0 {
+				continue
+			}
+			fd, _ := strconv.Atoi(name)
+			held[inode] = append(held[inode], fd)
+		}
+		for inode, fds := range held {
+			sort.Ints(fds)
+			inodeMap[inode] = append(inodeMap[inode], socketOwner{info: cached.info, fds: fds})
+		}
+	}
+
+	for _, owners := range inodeMap {
+		sort.Slice(owners, func(i, j int) bool {
+			return owners[i].info.pid < owners[j].info.pid
+		})
+	}
+	return inodeMap
+}
+
+// containerCounts returns how many distinct containers and pods the cached
+// processes belong to, and how many of those pods have no known name
+func (pc *processCache) containerCounts() (int, int, int) {
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+
+	containers := make(map[string]bool)
+	pods := make(map[string]bool) // pod uid -> whether its name is known
+	for _, cached := range pc.procs {
+		c := cached.info.container
+		if c.id != "" {
+			containers[c.id] = true
+		}
+		if c.podUID != "" {
+			pods[c.podUID] = pods[c.podUID] || c.podName != ""
+		}
+	}
+
+	unnamed := 0
+	for _, named := range pods {
+		if !named {
+			unnamed++
+		}
+	}
+	return len(containers), len(pods), unnamed
+}
+
+// parseSocketLink returns the inode of an fd link of the form socket:[inode],
+// or 0 for links to anything else
+func parseSocketLink(link string) int64 {
+	if !strings.HasPrefix(link, "socket:[") || !strings.HasSuffix(link, "]") {
+		return 0
+	}
+	inode, err := strconv.ParseInt(link[8:len(link)-1], 10, 64)
+	if err != nil {
+		return 0
+	}
+	return inode
+}
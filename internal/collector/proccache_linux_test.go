@@ -0,0 +1,383 @@
+//go:build linux
+
+package collector
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// writeFakeProcess creates /proc/<pid>/stat, /proc/<pid>/status and an fd
+// directory whose links point at the given targets
+func writeFakeProcess(tb testing.TB, root string, pid int, comm string, start int, fds map[int]string) {
+	tb.Helper()
+
+	dir := filepath.Join(root, strconv.Itoa(pid))
+	if err := os.MkdirAll(filepath.Join(dir, "fd"), 0o755); err != nil {
+		tb.Fatal(err)
+	}
+
+	stat := fmt.Sprintf("%d (%s) S 1 %d %d 0 -1 4194560 0 0 0 0 0 0 0 0 20 0 1 0 %d 0 0\n", pid, comm, pid, pid, start)
+	if err := os.WriteFile(filepath.Join(dir, "stat"), []byte(stat), 0o644); err != nil {
+		tb.Fatal(err)
+	}
+
+	writeFakeStatus(tb, root, pid, comm, 1)
+
+	for fd, target := range fds {
+		if err := os.Symlink(target, filepath.Join(dir, "fd", strconv.Itoa(fd))); err != nil {
+			tb.Fatal(err)
+		}
+	}
+}
+
+// writeFakeStatus writes the status of a process and of its leader thread
+func writeFakeStatus(tb testing.TB, root string, pid int, comm string, switches int) {
+	tb.Helper()
+
+	status := fmt.Sprintf("Name:\t%s\nPPid:\t1\nFDSize:\t64\nvoluntary_ctxt_switches:\t%d\nnonvoluntary_ctxt_switches:\t0\n", comm, switches)
+	if err := os.WriteFile(filepath.Join(root, strconv.Itoa(pid), "status"), []byte(status), 0o644); err != nil {
+		tb.Fatal(err)
+	}
+	writeFakeThread(tb, root, pid, pid, comm, switches)
+}
+
+// writeFakeThread writes /proc/<pid>/task/<tid>/status
+func writeFakeThread(tb testing.TB, root string, pid, tid int, comm string, switches int) {
+	tb.Helper()
+
+	dir := filepath.Join(root, strconv.Itoa(pid), "task", strconv.Itoa(tid))
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		tb.Fatal(err)
+	}
+	status := fmt.Sprintf("Name:\t%s\nPPid:\t1\nFDSize:\t64\nvoluntary_ctxt_switches:\t%d\nnonvoluntary_ctxt_switches:\t0\n", comm, switches)
+	if err := os.WriteFile(filepath.Join(dir, "status"), []byte(status), 0o644); err != nil {
+		tb.Fatal(err)
+	}
+}
+
+// setFakeFd opens, replaces or with an empty target closes an fd, counting
+// a context switch of the process
+func setFakeFd(tb testing.TB, root string, pid, fd int, target string) {
+	tb.Helper()
+	setFakeFdQuiet(tb, root, pid, fd, target)
+
+	data, err := os.ReadFile(filepath.Join(root, strconv.Itoa(pid), "status"))
+	if err != nil {
+		tb.Fatal(err)
+	}
+	var comm string
+	var switches int
+	for _, line := range strings.Split(string(data), "\n") {
+		key, value, _ := strings.Cut(line, ":")
+		switch key {
+		case "Name":
+			comm = strings.TrimSpace(value)
+		case "voluntary_ctxt_switches":
+			switches, _ = strconv.Atoi(strings.TrimSpace(value))
+		}
+	}
+	writeFakeStatus(tb, root, pid, comm, switches+1)
+}
+
+// setFakeFdQuiet changes an fd without the process appearing to have run
+func setFakeFdQuiet(tb testing.TB, root string, pid, fd int, target string) {
+	tb.Helper()
+
+	path := filepath.Join(root, strconv.Itoa(pid), "fd", strconv.Itoa(fd))
+	os.Remove(path)
+	if target == "" {
+		return
+	}
+	if err := os.Symlink(target, path); err != nil {
+		tb.Fatal(err)
+	}
+}
+
+// countingLoader loads fake process info and counts how often it is asked to
+func countingLoader(root string, loads *int) func(int) (*processInfo, error) {
+	return func(pid int) (*processInfo, error) {
+		*loads++
+		comm, err := os.ReadFile(filepath.Join(root, strconv.Itoa(pid), "stat"))
+		if err != nil {
+			return nil, err
+		}
+		return &processInfo{pid: pid, command: string(comm)}, nil
+	}
+}
+
+func ownerPIDs(owners []socketOwner) []int {
+	var pids []int
+	for _, owner := range owners {
+		pids = append(pids, owner.info.pid)
+	}
+	return pids
+}
+
+func equalInts(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestProcessCacheIncremental(t *testing.T) {
+	root := t.TempDir()
+	writeFakeProcess(t, root, 100, "nginx", 500, map[int]string{
+		0: "/dev/null",
+		3: "socket:[1001]",
+		4: "socket:[1002]",
+	})
+	writeFakeProcess(t, root, 200, "sshd", 600, map[int]string{
+		3: "socket:[2001]",
+	})
+
+	loads := 0
+	pc := newProcessCache(procFS{root: root}, countingLoader(root, &loads))
+
+	inodeMap, err := pc.inodeMap()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(inodeMap) != 3 || !equalInts(ownerPIDs(inodeMap[1001]), []int{100}) || !equalInts(ownerPIDs(inodeMap[2001]), []int{200}) {
+		t.Fatalf("unexpected initial map %v", inodeMap)
+	}
+	if loads != 2 {
+		t.Fatalf("expected 2 process loads, got %d", loads)
+	}
+
+	// a new socket, a closed socket and an exited process
+	setFakeFd(t, root, 100, 5, "socket:[1003]")
+	setFakeFd(t, root, 100, 4, "")
+	os.RemoveAll(filepath.Join(root, "200"))
+
+	inodeMap, err = pc.inodeMap()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, exists := inodeMap[1002]; exists {
+		t.Error("closed socket should be dropped")
+	}
+	if _, exists := inodeMap[2001]; exists {
+		t.Error("socket of exited process should be dropped")
+	}
+	if pids := ownerPIDs(inodeMap[1003]); !equalInts(pids, []int{100}) {
+		t.Errorf("new socket should belong to pid 100, got %v", pids)
+	}
+	if _, exists := pc.procs[200]; exists {
+		t.Error("exited process should be removed from the cache")
+	}
+	if loads != 2 {
+		t.Errorf("unchanged process should not be reloaded, got %d loads", loads)
+	}
+
+	// the pid is reused by a different process
+	os.RemoveAll(filepath.Join(root, "100"))
+	writeFakeProcess(t, root, 100, "redis", 900, map[int]string{3: "socket:[3001]"})
+
+	inodeMap, err = pc.inodeMap()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loads != 3 {
+		t.Errorf("reused pid should be reloaded, got %d loads", loads)
+	}
+	if _, exists := inodeMap[1001]; exists {
+		t.Error("sockets of the previous process should be dropped")
+	}
+	if pids := ownerPIDs(inodeMap[3001]); !equalInts(pids, []int{100}) {
+		t.Errorf("expected owner of 3001 to be pid 100, got %v", pids)
+	}
+}
+
+func TestProcessCacheResolveReusedFd(t *testing.T) {
+	root := t.TempDir()
+	writeFakeProcess(t, root, 100, "nginx", 500, map[int]string{3: "socket:[1001]"})
+
+	pc := newProcessCache(procFS{root: root}, countingLoader(root, new(int)))
+	if _, err := pc.inodeMap(); err != nil {
+		t.Fatal(err)
+	}
+
+	// fd 3 is closed and reused for another socket between two refreshes
+	setFakeFd(t, root, 100, 3, "socket:[1002]")
+
+	inodeMap, err := pc.inodeMap()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, exists := inodeMap[1002]; exists {
+		t.Fatal("reused fd should not be read again without a miss")
+	}
+
+	conns := []Connection{{Inode: 1002}, {Inode: 4242}}
+	pc.resolve(conns, inodeMap)
+
+	if conns[0].PID != 100 {
+		t.Errorf("expected reused fd to resolve to pid 100, got %d", conns[0].PID)
+	}
+	if conns[1].PID != 0 {
+		t.Errorf("expected kernel socket to stay unowned, got %d", conns[1].PID)
+	}
+	if _, known := pc.unowned[4242]; !known {
+		t.Error("unresolved inode should be remembered")
+	}
+	if _, known := pc.unowned[1002]; known {
+		t.Error("resolved inode should not be remembered as unowned")
+	}
+}
+
+func TestProcessCacheSkipsIdleProcesses(t *testing.T) {
+	root := t.TempDir()
+	writeFakeProcess(t, root, 100, "nginx", 500, map[int]string{3: "socket:[1001]"})
+
+	pc := newProcessCache(procFS{root: root}, countingLoader(root, new(int)))
+	if _, err := pc.inodeMap(); err != nil {
+		t.Fatal(err)
+	}
+
+	// the fd table changes but the process was never scheduled, so its fd
+	// directory is not listed again
+	setFakeFdQuiet(t, root, 100, 4, "socket:[1002]")
+
+	inodeMap, err := pc.inodeMap()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, exists := inodeMap[1002]; exists {
+		t.Fatal("fds of an idle process should not be listed")
+	}
+
+	// once it ran, the new fd is picked up
+	setFakeFd(t, root, 100, 5, "socket:[1003]")
+
+	inodeMap, err = pc.inodeMap()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, inode := range []int64{1001, 1002, 1003} {
+		if pids := ownerPIDs(inodeMap[inode]); !equalInts(pids, []int{100}) {
+			t.Errorf("expected owner of %d to be pid 100, got %v", inode, pids)
+		}
+	}
+}
+
+func TestProcessCacheWorkerThreads(t *testing.T) {
+	root := t.TempDir()
+	writeFakeProcess(t, root, 100, "java", 500, map[int]string{3: "socket:[1001]"})
+	writeFakeThread(t, root, 100, 101, "java", 1)
+
+	pc := newProcessCache(procFS{root: root}, countingLoader(root, new(int)))
+	if _, err := pc.inodeMap(); err != nil {
+		t.Fatal(err)
+	}
+
+	// a worker thread opens a socket while the leader keeps sleeping
+	setFakeFdQuiet(t, root, 100, 4, "socket:[1002]")
+	writeFakeThread(t, root, 100, 101, "java", 2)
+
+	inodeMap, err := pc.inodeMap()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pids := ownerPIDs(inodeMap[1002]); !equalInts(pids, []int{100}) {
+		t.Fatalf("socket opened by a worker thread should belong to pid 100, got %v", pids)
+	}
+
+	// a new thread opens a socket right away
+	setFakeFdQuiet(t, root, 100, 5, "socket:[1003]")
+	writeFakeThread(t, root, 100, 102, "java", 1)
+
+	inodeMap, err = pc.inodeMap()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pids := ownerPIDs(inodeMap[1003]); !equalInts(pids, []int{100}) {
+		t.Errorf("socket opened by a new thread should belong to pid 100, got %v", pids)
+	}
+}
+
+func TestProcessCacheSharedSockets(t *testing.T) {
+	root := t.TempDir()
+
+	// a listener inherited by two workers, and dup'ed within the master
+	writeFakeProcess(t, root, 300, "nginx", 500, map[int]string{6: "socket:[7001]", 9: "socket:[7001]"})
+	writeFakeProcess(t, root, 302, "nginx", 510, map[int]string{6: "socket:[7001]"})
+	writeFakeProcess(t, root, 301, "nginx", 505, map[int]string{6: "socket:[7001]", 7: "socket:[7002]"})
+
+	pc := newProcessCache(procFS{root: root}, countingLoader(root, new(int)))
+	inodeMap, err := pc.inodeMap()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	owners := inodeMap[7001]
+	if pids := ownerPIDs(owners); !equalInts(pids, []int{300, 301, 302}) {
+		t.Fatalf("expected owners sorted by pid, got %v", pids)
+	}
+	if !equalInts(owners[0].fds, []int{6, 9}) {
+		t.Errorf("expected master to hold fds 6 and 9, got %v", owners[0].fds)
+	}
+
+	conn := Connection{Inode: 7001}
+	attachProcess(&conn, inodeMap)
+	if conn.PID != 300 || len(conn.Owners) != 3 {
+		t.Fatalf("expected pid 300 with 3 owners, got %d with %v", conn.PID, conn.Owners)
+	}
+	if conn.Owners[2].PID != 302 || !equalInts(conn.Owners[2].FDs, []int{6}) {
+		t.Errorf("unexpected last owner %+v", conn.Owners[2])
+	}
+}
+
+// highVolumeProc builds a fake /proc with one process per connection of the
+// high-volume fixture, each holding its socket among fdsPerProcess fds
+func highVolumeProc(b *testing.B, fdsPerProcess int) string {
+	root := b.TempDir()
+
+	for i, conn := range generateHighVolumeConnections(1000) {
+		fds := make(map[int]string, fdsPerProcess)
+		for fd := 0; fd < fdsPerProcess-1; fd++ {
+			fds[fd] = fmt.Sprintf("/var/log/worker-%d.log", fd)
+		}
+		fds[fdsPerProcess-1] = fmt.Sprintf("socket:[%d]", 10000+i)
+		writeFakeProcess(b, root, conn.PID, conn.Process, 100+i, fds)
+	}
+
+	return root
+}
+
+func BenchmarkInodeMapFullScan(b *testing.B) {
+	root := highVolumeProc(b, 20)
+	load := countingLoader(root, new(int))
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := newProcessCache(procFS{root: root}, load).inodeMap(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkInodeMapIncremental(b *testing.B) {
+	root := highVolumeProc(b, 20)
+	pc := newProcessCache(procFS{root: root}, countingLoader(root, new(int)))
+	if _, err := pc.inodeMap(); err != nil {
+		b.Fatal(err)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := pc.inodeMap(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
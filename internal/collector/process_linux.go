@@ -0,0 +1,96 @@
+//go:build linux
+
+package collector
+
+import (
+	"bufio"
+	"bytes"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// userHZ is the unit of the clock tick fields in /proc/<pid>/stat. the
+// kernel exports it as 100 on every architecture.
+const userHZ = 100
+
+var (
+	bootTimeOnce sync.Once
+	bootTime     time.Time
+)
+
+// formatCmdline turns the NUL separated /proc/<pid>/cmdline into a space
+// separated command line
+func formatCmdline(data []byte) string {
+	data = bytes.TrimRight(data, "\x00")
+	return string(bytes.ReplaceAll(data, []byte{0}, []byte{' '}))
+}
+
+// readProcessStartTime returns when a process started, from the starttime
+// field of /proc/<pid>/stat and the boot time in /proc/stat
+func readProcessStartTime(pid int) time.Time {
+	data, err := procfs.readPidFile(pid, "stat")
+	if err != nil {
+		return time.Time{}
+	}
+
+	ticks, ok := parseStatStartTime(string(data))
+	if !ok {
+		return time.Time{}
+	}
+
+	boot := getBootTime()
+	if boot.IsZero() {
+		return time.Time{}
+	}
+	return boot.Add(time.Duration(ticks) * time.Second / userHZ)
+}
+
+// parseStatStartTime extracts starttime, field 22 of /proc/<pid>/stat. the
+// command name in field 2 may contain spaces and parentheses, so fields are
+// counted from its closing parenthesis.
+func parseStatStartTime(stat string) (int64, bool) {
+	end := strings.LastIndexByte(stat, ')')
+	if end < 0 {
+		return 0, false
+	}
+
+	// fields after the command start at field 3 (state)
+	fields := strings.Fields(stat[end+1:])
+	if len(fields) < 20 {
+		return 0, false
+	}
+
+	ticks, err := strconv.ParseInt(fields[19], 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return ticks, true
+}
+
+// getBootTime reads the btime line of /proc/stat once
+func getBootTime() time.Time {
+	bootTimeOnce.Do(func() {
+		file, err := os.Open(procfs.path("stat"))
+		if err != nil {
+			return
+		}
+		defer file.Close()
+
+		scanner := bufio.NewScanner(file)
+		for scanner.Scan() {
+			line := scanner.Text()
+			if !strings.HasPrefix(line, "btime ") {
+				continue
+			}
+			secs, err := strconv.ParseInt(strings.TrimSpace(line[6:]), 10, 64)
+			if err == nil {
+				bootTime = time.Unix(secs, 0)
+			}
+			return
+		}
+	})
+	return bootTime
+}
@@ -0,0 +1,51 @@
+//go:build linux
+
+package collector
+
+import "testing"
+
+func TestParseStatStartTime(t *testing.T) {
+	testCases := []struct {
+		name     string
+		stat     string
+		expected int64
+		ok       bool
+	}{
+		{
+			name:     "plain command",
+			stat:     "1234 (nginx) S 1 1234 1234 0 -1 4194560 1050 0 0 0 3 5 0 0 20 0 1 0 4521 10985472 1498 18446744073709551615",
+			expected: 4521,
+			ok:       true,
+		},
+		{
+			name:     "command with spaces and parens",
+			stat:     "42 (tmux: server (1)) S 1 42 42 0 -1 4194560 300 0 0 0 1 1 0 0 20 0 1 0 987654 8716288 900 18446744073709551615",
+			expected: 987654,
+			ok:       true,
+		},
+		{
+			name: "truncated",
+			stat: "42 (sh) S 1 42",
+		},
+		{
+			name: "no command",
+			stat: "garbage",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ticks, ok := parseStatStartTime(tc.stat)
+			if ok != tc.ok || ticks != tc.expected {
+				t.Errorf("expected (%d, %v), got (%d, %v)", tc.expected, tc.ok, ticks, ok)
+			}
+		})
+	}
+}
+
+func TestFormatCmdline(t *testing.T) {
+	got := formatCmdline([]byte("/usr/bin/python3\x00manage.py\x00runserver\x00"))
+	if got != "/usr/bin/python3 manage.py runserver" {
+		t.Errorf("unexpected cmdline %q", got)
+	}
+}
@@ -0,0 +1,75 @@
+//go:build linux
+
+package collector
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+)
+
+// procFS is the proc filesystem mounted at root. every /proc path the linux
+// collector reads is built here, so it can inspect a host's /proc mounted
+// into a container (e.g. /host/proc) or a captured tree in tests.
+type procFS struct {
+	root string
+}
+
+// procfs is the proc filesystem the collector reads
+var procfs = procFS{root: DefaultProcRoot}
+
+// SetProcRoot points the collector at the proc filesystem mounted at root.
+// it has to be called before the first collection.
+func SetProcRoot(root string) error {
+	if root == "" {
+		root = DefaultProcRoot
+	}
+
+	fs := procFS{root: filepath.Clean(root)}
+	if _, err := os.Stat(fs.path("stat")); err != nil {
+		return fmt.Errorf("proc root %s does not look like a proc filesystem: %w", root, err)
+	}
+
+	procfs = fs
+	processes = newProcessCache(fs, getProcessInfo)
+	bootTimeOnce = sync.Once{}
+	return nil
+}
+
+// path returns the path of elem relative to the proc root
+func (p procFS) path(elem ...string) string {
+	return filepath.Join(append([]string{p.root}, elem...)...)
+}
+
+// pidPath returns the path of elem within /proc/<pid>
+func (p procFS) pidPath(pid int, elem ...string) string {
+	return p.path(append([]string{strconv.Itoa(pid)}, elem...)...)
+}
+
+// netDir is the /proc/<pid>/net view of the namespace pid lives in
+func (p procFS) netDir(pid int) string {
+	return p.pidPath(pid, "net")
+}
+
+// selfNetDir is the net view of the namespace snitch runs in
+func (p procFS) selfNetDir() string {
+	return p.path("self", "net")
+}
+
+func (p procFS) readFile(elem ...string) ([]byte, error) {
+	return os.ReadFile(p.path(elem...))
+}
+
+func (p procFS) readPidFile(pid int, elem ...string) ([]byte, error) {
+	return os.ReadFile(p.pidPath(pid, elem...))
+}
+
+func (p procFS) readPidLink(pid int, elem ...string) (string, error) {
+	return os.Readlink(p.pidPath(pid, elem...))
+}
+
+func (p procFS) readDir(elem ...string) ([]os.DirEntry, error) {
+	return os.ReadDir(p.path(elem...))
+}
@@ -0,0 +1,90 @@
+//go:build linux
+
+package collector
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeProcTree writes files and symlinks ("-> target") below root
+func writeProcTree(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+
+		var err error
+		if len(content) > 3 && content[:3] == "-> " {
+			err = os.Symlink(content[3:], path)
+		} else {
+			err = os.WriteFile(path, []byte(content), 0o644)
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestSetProcRootRejectsNonProc(t *testing.T) {
+	if err := SetProcRoot(t.TempDir()); err == nil {
+		t.Error("expected an error for a directory without /proc/stat")
+	}
+	if procfs.root != DefaultProcRoot {
+		t.Errorf("failed SetProcRoot should keep %s, got %s", DefaultProcRoot, procfs.root)
+	}
+}
+
+func TestCollectorReadsCapturedProcTree(t *testing.T) {
+	root := t.TempDir()
+	writeProcTree(t, root, map[string]string{
+		"stat":           "cpu  0 0 0 0\nbtime 1700000000\n",
+		"self/ns/net":    "-> net:[4026531840]",
+		"1/ns/net":       "-> net:[4026531840]",
+		"1/stat":         "1 (systemd) S 0 1 1 0 -1 4194560 0 0 0 0 0 0 0 0 20 0 1 0 5 0 0\n",
+		"1/comm":         "systemd\n",
+		"1/status":       "Name:\tsystemd\nTgid:\t1\nPPid:\t0\nUid:\t0\t0\t0\t0\n",
+		"1/fd/0":         "-> /dev/null",
+		"812/ns/net":     "-> net:[4026531840]",
+		"812/stat":       "812 (sshd) S 1 812 812 0 -1 4194560 0 0 0 0 0 0 0 0 20 0 1 0 1000 0 0\n",
+		"812/comm":       "sshd\n",
+		"812/cmdline":    "/usr/sbin/sshd\x00-D\x00",
+		"812/status":     "Name:\tsshd\nTgid:\t812\nPPid:\t1\nUid:\t0\t0\t0\t0\n",
+		"812/fd/3":       "-> socket:[31337]",
+		"1/net/tcp":      "  sl  local_address rem_address   st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode\n   0: 00000000:0016 00000000:0000 0A 00000000:00000000 00:00000000 00000000     0        0 31337 1 0000000000000000 100 0 0 10 0\n",
+		"1/net/udp":      "  sl  local_address rem_address   st tx_queue rx_queue tr tm->when ref pointer drops\n",
+		"self/net/route": "Iface\tDestination\tGateway\tFlags\tRefCnt\tUse\tMetric\tMask\n",
+	})
+
+	if err := SetProcRoot(root); err != nil {
+		t.Fatalf("SetProcRoot failed: %v", err)
+	}
+	t.Cleanup(func() {
+		if err := SetProcRoot(DefaultProcRoot); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	conns, err := (&DefaultCollector{}).GetConnections()
+	if err != nil {
+		t.Fatalf("GetConnections failed: %v", err)
+	}
+	if len(conns) != 1 {
+		t.Fatalf("expected the sshd listener, got %v", conns)
+	}
+
+	sshd := conns[0]
+	if sshd.PID != 812 || sshd.Process != "sshd" || sshd.Lport != 22 || sshd.State != "LISTEN" {
+		t.Errorf("unexpected connection %+v", sshd)
+	}
+	if sshd.Cmdline != "/usr/sbin/sshd -D" || sshd.PPID != 1 {
+		t.Errorf("expected process details from the captured tree, got cmdline %q ppid %d", sshd.Cmdline, sshd.PPID)
+	}
+	if sshd.StartTime.Unix() != 1700000010 {
+		t.Errorf("expected start time from the captured btime, got %v", sshd.StartTime)
+	}
+}
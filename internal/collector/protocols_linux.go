@@ -0,0 +1,258 @@
+//go:build linux
+
+package collector
+
+import (
+	"bufio"
+	"net/netip"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// sctp association states from include/net/sctp/constants.h
+var sctpStates = map[int64]string{
+	0: "CLOSED",
+	1: "COOKIE_WAIT",
+	2: "COOKIE_ECHOED",
+	3: "ESTABLISHED",
+	4: "SHUTDOWN_PENDING",
+	5: "SHUTDOWN_SENT",
+	6: "SHUTDOWN_RECEIVED",
+	7: "SHUTDOWN_ACK_SENT",
+}
+
+// parseProcPacket parses /proc/net/packet. packet sockets have no addresses:
+// the bound interface becomes the local address and the ethertype, e.g.
+// 0x0003 for ETH_P_ALL, the local port, much like raw sockets report their
+// ip protocol.
+func parseProcPacket(path string, inodeMap map[int64][]socketOwner, ifaces *interfaceTable) ([]Connection, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var connections []Connection
+	scanner := bufio.NewScanner(file)
+
+	scanner.Scan()
+
+	for scanner.Scan() {
+		// sk RefCnt Type Proto Iface R Rmem User Inode
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 9 {
+			continue
+		}
+
+		ethertype, err := strconv.ParseInt(fields[3], 16, 32)
+		if err != nil {
+			continue
+		}
+		index, err := strconv.Atoi(fields[4])
+		if err != nil {
+			continue
+		}
+		uid, uidErr := strconv.Atoi(fields[7])
+		inode, _ := strconv.ParseInt(fields[8], 10, 64)
+
+		state := "UNCONNECTED"
+		if fields[5] == "1" {
+			state = "RUNNING"
+		}
+
+		iface := ifaces.name(index)
+		conn := Connection{
+			TS:        time.Now(),
+			Proto:     "packet",
+			State:     state,
+			Laddr:     iface,
+			Lport:     int(ethertype),
+			Raddr:     "*",
+			Interface: iface,
+			Inode:     inode,
+		}
+
+		if uidErr == nil {
+			setSocketUID(&conn, uid)
+		}
+		attachProcess(&conn, inodeMap)
+
+		connections = append(connections, conn)
+	}
+
+	return connections, scanner.Err()
+}
+
+// parseProcSCTPEndpoints parses /proc/net/sctp/eps, which lists bound sctp
+// sockets. multi-homed endpoints are reported with their first address.
+func parseProcSCTPEndpoints(path string, inodeMap map[int64][]socketOwner) ([]Connection, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var connections []Connection
+	scanner := bufio.NewScanner(file)
+
+	scanner.Scan()
+
+	for scanner.Scan() {
+		// ENDPT SOCK STY SST HBKT LPORT UID INODE LADDRS...
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 8 {
+			continue
+		}
+
+		sockState, err := strconv.ParseInt(fields[3], 10, 32)
+		if err != nil {
+			continue
+		}
+		lport, err := strconv.Atoi(fields[5])
+		if err != nil {
+			continue
+		}
+		uid, uidErr := strconv.Atoi(fields[6])
+		inode, _ := strconv.ParseInt(fields[7], 10, 64)
+
+		laddr, ipVersion := "*", 4
+		if len(fields) > 8 {
+			laddr, ipVersion = formatSCTPAddr(fields[8])
+		}
+
+		conn := Connection{
+			TS:        time.Now(),
+			Proto:     "sctp",
+			IPVersion: "IPv" + strconv.Itoa(ipVersion),
+			State:     stateName(sockState, "tcp"),
+			Laddr:     laddr,
+			Lport:     lport,
+			Raddr:     "*",
+			Inode:     inode,
+		}
+
+		if uidErr == nil {
+			setSocketUID(&conn, uid)
+		}
+		attachProcess(&conn, inodeMap)
+
+		connections = append(connections, conn)
+	}
+
+	return connections, scanner.Err()
+}
+
+// parseProcSCTPAssocs parses /proc/net/sctp/assocs. the remote address is
+// the primary path, which the kernel marks with a leading '*'.
+func parseProcSCTPAssocs(path string, inodeMap map[int64][]socketOwner) ([]Connection, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var connections []Connection
+	scanner := bufio.NewScanner(file)
+
+	scanner.Scan()
+
+	for scanner.Scan() {
+		// ASSOC SOCK STY SST ST HBKT ASSOC-ID TX_QUEUE RX_QUEUE UID INODE
+		// LPORT RPORT LADDRS <-> RADDRS HBINT INS OUTS ...
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 13 {
+			continue
+		}
+
+		assocState, err := strconv.ParseInt(fields[4], 10, 32)
+		if err != nil {
+			continue
+		}
+		sendQ, _ := strconv.Atoi(fields[7])
+		recvQ, _ := strconv.Atoi(fields[8])
+		uid, uidErr := strconv.Atoi(fields[9])
+		inode, _ := strconv.ParseInt(fields[10], 10, 64)
+		lport, err := strconv.Atoi(fields[11])
+		if err != nil {
+			continue
+		}
+		rport, err := strconv.Atoi(fields[12])
+		if err != nil {
+			continue
+		}
+
+		laddr, raddr, ipVersion := "*", "*", 4
+		var locals, remotes []string
+		for i := 13; i < len(fields); i++ {
+			if fields[i] == "<->" {
+				for _, f := range fields[i+1:] {
+					if !strings.ContainsAny(f, ".:") {
+						break
+					}
+					remotes = append(remotes, f)
+				}
+				break
+			}
+			locals = append(locals, fields[i])
+		}
+		if len(locals) > 0 {
+			laddr, ipVersion = formatSCTPAddr(primarySCTPAddr(locals))
+		}
+		if len(remotes) > 0 {
+			raddr, _ = formatSCTPAddr(primarySCTPAddr(remotes))
+		}
+
+		conn := Connection{
+			TS:        time.Now(),
+			Proto:     "sctp",
+			IPVersion: "IPv" + strconv.Itoa(ipVersion),
+			State:     sctpStates[assocState],
+			Laddr:     laddr,
+			Lport:     lport,
+			Raddr:     raddr,
+			Rport:     rport,
+			Inode:     inode,
+			SendQ:     sendQ,
+			RecvQ:     recvQ,
+		}
+
+		if uidErr == nil {
+			setSocketUID(&conn, uid)
+		}
+		attachProcess(&conn, inodeMap)
+
+		connections = append(connections, conn)
+	}
+
+	return connections, scanner.Err()
+}
+
+// primarySCTPAddr returns the address of a multi-homed list that the kernel
+// marks as the primary path with a leading "*", or the first one
+func primarySCTPAddr(addrs []string) string {
+	for _, addr := range addrs {
+		if strings.HasPrefix(addr, "*") {
+			return addr[1:]
+		}
+	}
+	return addrs[0]
+}
+
+// formatSCTPAddr renders an address printed by %pI4 or %pI6 the way
+// parseHexAddr renders /proc addresses, including "*" for the unspecified one
+func formatSCTPAddr(s string) (string, int) {
+	addr, err := netip.ParseAddr(s)
+	if err != nil {
+		return s, 4
+	}
+
+	if addr.Is4() {
+		raw := addr.As4()
+		return formatDiagAddr(syscall.AF_INET, raw[:]), 4
+	}
+	raw := addr.As16()
+	return formatDiagAddr(syscall.AF_INET6, raw[:]), 6
+}
@@ -0,0 +1,133 @@
+//go:build linux
+
+package collector
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseProcPacket(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "packet")
+	content := `sk               RefCnt Type Proto  Iface R Rmem   User   Inode
+ffff8881034e6000 3      3    0003   0     1 0      0      51234
+ffff8881034e7000 3      2    0800   2     0 0      1000   51235
+`
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write fixture: %v", err)
+	}
+
+	ifaces := newInterfaceTable()
+	ifaces.names[2] = "eth0"
+	inodeMap := map[int64][]socketOwner{51234: {{info: &processInfo{pid: 4242, command: "tcpdump"}}}}
+
+	conns, err := parseProcPacket(path, inodeMap, ifaces)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(conns) != 2 {
+		t.Fatalf("expected 2 sockets, got %d", len(conns))
+	}
+
+	sniffer := conns[0]
+	if sniffer.Proto != "packet" || sniffer.Process != "tcpdump" {
+		t.Errorf("expected packet socket owned by tcpdump, got %s owned by %q", sniffer.Proto, sniffer.Process)
+	}
+	if sniffer.Lport != 3 || sniffer.Interface != "*" || sniffer.State != "RUNNING" {
+		t.Errorf("expected ETH_P_ALL on every interface, got ethertype %d on %q (%s)", sniffer.Lport, sniffer.Interface, sniffer.State)
+	}
+
+	if conns[1].Lport != 0x0800 || conns[1].Laddr != "eth0" || conns[1].State != "UNCONNECTED" {
+		t.Errorf("expected ipv4 socket bound to eth0, got %#x on %q (%s)", conns[1].Lport, conns[1].Laddr, conns[1].State)
+	}
+}
+
+func TestParseProcRawAndICMP(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"raw": `  sl  local_address rem_address   st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode ref pointer drops
+   1: 00000000:0001 00000000:0000 07 00000000:00000000 00:00000000 00000000     0        0 6001 2 0000000000000000 0
+`,
+		"icmp": `  sl  local_address rem_address   st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode ref pointer drops
+   1: 00000000:0007 00000000:0000 07 00000000:00000000 00:00000000 00000000  1000        0 6002 2 0000000000000000 0
+`,
+		"udplite": `   sl  local_address rem_address   st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode ref pointer drops
+  12: 00000000:1388 00000000:0000 07 00000000:00000000 00:00000000 00000000     0        0 6003 2 0000000000000000 0
+`,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+	}
+
+	conns := parseProcExtraTables(dir, map[int64][]socketOwner{}, newInterfaceTable(), nil)
+
+	byProto := make(map[string]Connection)
+	for _, c := range conns {
+		byProto[c.Proto] = c
+	}
+
+	if raw, ok := byProto["raw"]; !ok || raw.Lport != 1 || raw.State != "UNCONNECTED" {
+		t.Errorf("expected unconnected raw icmp socket, got %+v", raw)
+	}
+	if icmp, ok := byProto["icmp"]; !ok || icmp.Inode != 6002 {
+		t.Errorf("expected ping socket with inode 6002, got %+v", icmp)
+	}
+	if lite, ok := byProto["udplite"]; !ok || lite.Lport != 5000 || lite.State != "LISTEN" {
+		t.Errorf("expected udplite listener on 5000, got %+v", lite)
+	}
+}
+
+func TestParseProcSCTP(t *testing.T) {
+	dir := t.TempDir()
+	eps := filepath.Join(dir, "eps")
+	assocs := filepath.Join(dir, "assocs")
+
+	if err := os.WriteFile(eps, []byte(` ENDPT     SOCK   STY SST HBKT LPORT   UID INODE LADDRS
+ffff88017e0a0200 ffff880299f7fa00 2   10  29  11165     0 21017 10.0.0.1 192.168.1.1
+ffff88017e0a0300 ffff880299f7fb00 1   10  30  3868      0 21018 ::
+`), 0644); err != nil {
+		t.Fatalf("failed to write eps: %v", err)
+	}
+	if err := os.WriteFile(assocs, []byte(` ASSOC     SOCK   STY SST ST HBKT ASSOC-ID TX_QUEUE RX_QUEUE UID INODE LPORT RPORT LADDRS <-> RADDRS HBINT INS OUTS MAXRT T1X T2X RTXC wmema wmemq sndbuf rcvbuf
+ffff88045ac7e000 ffff88062077aa00 2   1   3  1205  963        12        4       0 21019 11165 41046 10.0.0.1 <-> 10.0.0.9 *10.0.0.2 	    7500    10    10   10    0    0        0        1        0   212992   212992
+ffff88045ac7f000 ffff88062077ab00 2   1   3  1206  964         0        0       0 21020 11165 41047 192.168.1.1 *10.0.0.1 <-> *10.0.0.3 	    7500    10    10   10    0    0        0        1        0   212992   212992
+`), 0644); err != nil {
+		t.Fatalf("failed to write assocs: %v", err)
+	}
+
+	endpoints, err := parseProcSCTPEndpoints(eps, map[int64][]socketOwner{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(endpoints) != 2 {
+		t.Fatalf("expected 2 endpoints, got %d", len(endpoints))
+	}
+	if endpoints[0].Laddr != "10.0.0.1" || endpoints[0].Lport != 11165 || endpoints[0].State != "LISTEN" {
+		t.Errorf("expected listener on 10.0.0.1:11165, got %s:%d (%s)", endpoints[0].Laddr, endpoints[0].Lport, endpoints[0].State)
+	}
+	if endpoints[1].Laddr != "*" || endpoints[1].IPVersion != "IPv6" {
+		t.Errorf("expected ipv6 wildcard endpoint, got %s (%s)", endpoints[1].Laddr, endpoints[1].IPVersion)
+	}
+
+	associations, err := parseProcSCTPAssocs(assocs, map[int64][]socketOwner{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(associations) != 2 {
+		t.Fatalf("expected 2 associations, got %d", len(associations))
+	}
+	a := associations[0]
+	if a.Laddr != "10.0.0.1" || a.Raddr != "10.0.0.2" || a.Rport != 41046 {
+		t.Errorf("expected primary path 10.0.0.1 to 10.0.0.2:41046, got %s to %s:%d", a.Laddr, a.Raddr, a.Rport)
+	}
+	if a.State != "ESTABLISHED" || a.SendQ != 12 || a.RecvQ != 4 {
+		t.Errorf("expected established with queues 12/4, got %s %d/%d", a.State, a.SendQ, a.RecvQ)
+	}
+	// the primary local address is marked like the remote one
+	if b := associations[1]; b.Laddr != "10.0.0.1" || b.Raddr != "10.0.0.3" {
+		t.Errorf("expected primary path 10.0.0.1 to 10.0.0.3, got %s to %s", b.Laddr, b.Raddr)
+	}
+}
@@ -5,6 +5,9 @@ type Query struct {
 	Filter FilterOptions
 	Sort   SortOptions
 	Limit  int
+
+	// fields to aggregate by, see Groups
+	Group []string
 }
 
 // NewQuery creates a query with sensible defaults
@@ -40,6 +43,12 @@ func (q *Query) WithLimit(n int) *Query {
 	return q
 }
 
+// GroupBy aggregates the results by the values of fields
+func (q *Query) GroupBy(fields ...string) *Query {
+	q.Group = fields
+	return q
+}
+
 // Proto filters by protocol
 func (q *Query) Proto(proto string) *Query {
 	q.Filter.Proto = proto
@@ -130,6 +139,31 @@ func (q *Query) Apply(conns []Connection) []Connection {
 	return result
 }
 
+// ExecuteGroups runs the query and returns its groups
+func (q *Query) ExecuteGroups() ([]Group, error) {
+	conns, err := GetConnections()
+	if err != nil {
+		return nil, err
+	}
+
+	return q.Groups(conns)
+}
+
+// Groups filters conns and aggregates them by the query's group fields.
+// the limit applies to the groups, not to the connections in them.
+func (q *Query) Groups(conns []Connection) ([]Group, error) {
+	groups, err := GroupConnections(FilterConnections(conns, q.Filter), q.Group)
+	if err != nil {
+		return nil, err
+	}
+
+	if q.Limit > 0 && len(groups) > q.Limit {
+		groups = groups[:q.Limit]
+	}
+
+	return groups, nil
+}
+
 // common pre-built queries
 
 // ListeningTCP returns a query for TCP listeners
@@ -163,3 +163,29 @@ func TestPrebuiltQueries(t *testing.T) {
 	})
 }
 
+
+func TestQueryGroups(t *testing.T) {
+	conns := []Connection{
+		{Process: "nginx", Proto: "tcp", State: "ESTABLISHED", Raddr: "10.0.0.1"},
+		{Process: "nginx", Proto: "tcp", State: "ESTABLISHED", Raddr: "10.0.0.1"},
+		{Process: "nginx", Proto: "tcp", State: "ESTABLISHED", Raddr: "10.0.0.2"},
+		{Process: "nginx", Proto: "tcp", State: "LISTEN", Raddr: "*"},
+		{Process: "sshd", Proto: "tcp", State: "ESTABLISHED", Raddr: "10.0.0.3"},
+	}
+
+	groups, err := NewQuery().Established().GroupBy("process", "raddr").WithLimit(2).Groups(conns)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(groups) != 2 {
+		t.Fatalf("expected 2 groups (limit), got %d", len(groups))
+	}
+	if groups[0].Key["raddr"] != "10.0.0.1" || groups[0].Count != 2 {
+		t.Errorf("expected 2 connections to 10.0.0.1 first, got %+v", groups[0])
+	}
+	for _, g := range groups {
+		if g.Key["raddr"] == "*" {
+			t.Errorf("expected the listener to be filtered out, got %+v", g)
+		}
+	}
+}
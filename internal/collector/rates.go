@@ -0,0 +1,51 @@
+package collector
+
+import (
+	"fmt"
+	"time"
+)
+
+// RateTracker computes the throughput of connections from successive
+// snapshots, for commands that collect at an interval
+type RateTracker struct {
+	prev map[string]rateSample
+	last time.Time
+}
+
+type rateSample struct {
+	rx, tx int64
+}
+
+func NewRateTracker() *RateTracker {
+	return &RateTracker{}
+}
+
+// Update sets RxRate and TxRate of conns, collected at now, from the byte
+// counters of the previous snapshot. connections that are new or whose
+// counters went back, like a reused socket address, get no rate.
+func (t *RateTracker) Update(conns []Connection, now time.Time) {
+	elapsed := now.Sub(t.last).Seconds()
+	current := make(map[string]rateSample, len(conns))
+
+	for i := range conns {
+		c := &conns[i]
+		key := rateKey(*c)
+		current[key] = rateSample{rx: c.RxBytes, tx: c.TxBytes}
+
+		c.RxRate, c.TxRate = 0, 0
+		prev, ok := t.prev[key]
+		if !ok || elapsed <= 0 || c.RxBytes < prev.rx || c.TxBytes < prev.tx {
+			continue
+		}
+		c.RxRate = float64(c.RxBytes-prev.rx) / elapsed
+		c.TxRate = float64(c.TxBytes-prev.tx) / elapsed
+	}
+
+	t.prev = current
+	t.last = now
+}
+
+// rateKey identifies a socket across snapshots
+func rateKey(c Connection) string {
+	return fmt.Sprintf("%s|%d|%s|%d|%s|%d|%d", c.Proto, c.NamespaceInode, c.Laddr, c.Lport, c.Raddr, c.Rport, c.Inode)
+}
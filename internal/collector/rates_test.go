@@ -0,0 +1,45 @@
+package collector
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRateTracker(t *testing.T) {
+	start := time.Unix(1700000000, 0)
+	conn := func(lport int, rx, tx int64) Connection {
+		return Connection{Proto: "tcp", Laddr: "10.0.0.1", Lport: lport, Raddr: "10.0.0.2", Rport: 443, Inode: int64(lport), RxBytes: rx, TxBytes: tx}
+	}
+
+	tracker := NewRateTracker()
+
+	first := []Connection{conn(1000, 100, 50), conn(1001, 5000, 0)}
+	tracker.Update(first, start)
+	for _, c := range first {
+		if c.RxRate != 0 || c.TxRate != 0 {
+			t.Errorf("first snapshot of %d: expected no rate, got rx %v tx %v", c.Lport, c.RxRate, c.TxRate)
+		}
+	}
+
+	second := []Connection{conn(1000, 2100, 550), conn(1001, 10, 0), conn(1002, 900, 900)}
+	tracker.Update(second, start.Add(2*time.Second))
+
+	tests := []struct {
+		lport  int
+		rxRate float64
+		txRate float64
+	}{
+		{1000, 1000, 250},
+		{1001, 0, 0}, // counters went back
+		{1002, 0, 0}, // new connection
+	}
+	for i, tt := range tests {
+		c := second[i]
+		if c.Lport != tt.lport {
+			t.Fatalf("unexpected order: %d", c.Lport)
+		}
+		if c.RxRate != tt.rxRate || c.TxRate != tt.txRate {
+			t.Errorf("lport %d: expected rx %v tx %v, got rx %v tx %v", tt.lport, tt.rxRate, tt.txRate, c.RxRate, c.TxRate)
+		}
+	}
+}
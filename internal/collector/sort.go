@@ -1,6 +1,8 @@
 package collector
 
 import (
+	"cmp"
+	"net/netip"
 	"sort"
 	"strings"
 )
@@ -21,8 +23,14 @@ const (
 	SortByInterface  SortField = "if"
 	SortByRxBytes    SortField = "rx_bytes"
 	SortByTxBytes    SortField = "tx_bytes"
+	SortByRxRate     SortField = "rx/s"
+	SortByTxRate     SortField = "tx/s"
 	SortByRttMs      SortField = "rtt_ms"
 	SortByTimestamp  SortField = "ts"
+	SortBySendQ      SortField = "send_q"
+	SortByRecvQ      SortField = "recv_q"
+	SortByRetrans    SortField = "retrans"
+	SortByCwnd       SortField = "cwnd"
 )
 
 // SortDirection represents ascending or descending order
@@ -33,18 +41,86 @@ const (
 	SortDesc
 )
 
-// SortOptions configures how connections are sorted
+// SortFields lists every field connections can be sorted by, in the order
+// the tui cycles through them
+var SortFields = []SortField{
+	SortByLport,
+	SortByProcess,
+	SortByPID,
+	SortByState,
+	SortByProto,
+	SortByUser,
+	SortByLaddr,
+	SortByRaddr,
+	SortByRport,
+	SortByInterface,
+	SortByRxBytes,
+	SortByTxBytes,
+	SortByRxRate,
+	SortByTxRate,
+	SortByRttMs,
+	SortBySendQ,
+	SortByRecvQ,
+	SortByRetrans,
+	SortByCwnd,
+	SortByTimestamp,
+}
+
+// SortOptions configures how connections are sorted. Field and Direction
+// are the primary key, ties are broken by the keys in Then.
 type SortOptions struct {
 	Field     SortField
 	Direction SortDirection
+	Then      []SortKey
+}
+
+// SortKey is a tie-breaking key of a multi-key sort
+type SortKey struct {
+	Field     SortField
+	Direction SortDirection
+}
+
+// keys returns every key of the sort, primary first
+func (o SortOptions) keys() []SortKey {
+	return append([]SortKey{{Field: o.Field, Direction: o.Direction}}, o.Then...)
 }
 
-// ParseSortOptions parses a sort string like "pid:desc" or "lport"
+// String renders the options the way ParseSortOptions reads them
+func (o SortOptions) String() string {
+	parts := make([]string, 0, len(o.Then)+1)
+	for _, key := range o.keys() {
+		part := string(key.Field)
+		if key.Direction == SortDesc {
+			part += ":desc"
+		}
+		parts = append(parts, part)
+	}
+	return strings.Join(parts, ",")
+}
+
+// ParseSortOptions parses a sort string like "pid:desc", "lport" or a comma
+// separated list of keys such as "state,process,lport:desc"
 func ParseSortOptions(s string) SortOptions {
 	if s == "" {
 		return SortOptions{Field: SortByLport, Direction: SortAsc}
 	}
 
+	var keys []SortKey
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		keys = append(keys, parseSortKey(part))
+	}
+	if len(keys) == 0 {
+		return SortOptions{Field: SortByLport, Direction: SortAsc}
+	}
+
+	return SortOptions{Field: keys[0].Field, Direction: keys[0].Direction, Then: keys[1:]}
+}
+
+func parseSortKey(s string) SortKey {
 	parts := strings.SplitN(s, ":", 2)
 	field := SortField(strings.ToLower(parts[0]))
 	direction := SortAsc
@@ -53,7 +129,7 @@ func ParseSortOptions(s string) SortOptions {
 		direction = SortDesc
 	}
 
-	return SortOptions{Field: field, Direction: direction}
+	return SortKey{Field: field, Direction: direction}
 }
 
 // SortConnections sorts a slice of connections in place
@@ -62,48 +138,100 @@ func SortConnections(conns []Connection, opts SortOptions) {
 		return
 	}
 
+	keys := opts.keys()
 	sort.SliceStable(conns, func(i, j int) bool {
-		less := compareConnections(conns[i], conns[j], opts.Field)
-		if opts.Direction == SortDesc {
-			return !less
+		for _, key := range keys {
+			c := compareField(conns[i], conns[j], key.Field)
+			if key.Direction == SortDesc {
+				c = -c
+			}
+			if c != 0 {
+				return c < 0
+			}
 		}
-		return less
+		return false
 	})
 }
 
-func compareConnections(a, b Connection, field SortField) bool {
+// compareField compares a and b by field, returning -1, 0 or +1
+func compareField(a, b Connection, field SortField) int {
 	switch field {
 	case SortByPID:
-		return a.PID < b.PID
+		return cmp.Compare(a.PID, b.PID)
 	case SortByProcess:
-		return strings.ToLower(a.Process) < strings.ToLower(b.Process)
+		return cmp.Compare(strings.ToLower(a.Process), strings.ToLower(b.Process))
 	case SortByUser:
-		return strings.ToLower(a.User) < strings.ToLower(b.User)
+		return cmp.Compare(strings.ToLower(a.User), strings.ToLower(b.User))
 	case SortByProto:
-		return a.Proto < b.Proto
+		return cmp.Compare(a.Proto, b.Proto)
 	case SortByState:
-		return stateOrder(a.State) < stateOrder(b.State)
+		return cmp.Compare(stateOrder(a.State), stateOrder(b.State))
 	case SortByLaddr:
-		return a.Laddr < b.Laddr
+		return compareAddr(a.Laddr, b.Laddr)
 	case SortByLport:
-		return a.Lport < b.Lport
+		return cmp.Compare(a.Lport, b.Lport)
 	case SortByRaddr:
-		return a.Raddr < b.Raddr
+		return compareAddr(a.Raddr, b.Raddr)
 	case SortByRport:
-		return a.Rport < b.Rport
+		return cmp.Compare(a.Rport, b.Rport)
 	case SortByInterface:
-		return a.Interface < b.Interface
+		return cmp.Compare(a.Interface, b.Interface)
 	case SortByRxBytes:
-		return a.RxBytes < b.RxBytes
+		return cmp.Compare(a.RxBytes, b.RxBytes)
 	case SortByTxBytes:
-		return a.TxBytes < b.TxBytes
+		return cmp.Compare(a.TxBytes, b.TxBytes)
+	case SortByRxRate:
+		return cmp.Compare(a.RxRate, b.RxRate)
+	case SortByTxRate:
+		return cmp.Compare(a.TxRate, b.TxRate)
 	case SortByRttMs:
-		return a.RttMs < b.RttMs
+		return cmp.Compare(a.RttMs, b.RttMs)
 	case SortByTimestamp:
-		return a.TS.Before(b.TS)
+		return a.TS.Compare(b.TS)
+	case SortBySendQ:
+		return cmp.Compare(a.SendQ, b.SendQ)
+	case SortByRecvQ:
+		return cmp.Compare(a.RecvQ, b.RecvQ)
+	case SortByRetrans:
+		return cmp.Compare(a.Retransmits, b.Retransmits)
+	case SortByCwnd:
+		return cmp.Compare(a.Cwnd, b.Cwnd)
+	default:
+		return cmp.Compare(a.Lport, b.Lport)
+	}
+}
+
+// compareAddr orders addresses numerically: the wildcard "*" first, then
+// ipv4 before ipv6 addresses, ipv4-mapped ipv6 ones among ipv4, and
+// anything that is not an ip, like unix socket paths, last by name
+func compareAddr(a, b string) int {
+	ipA, okA := sortableAddr(a)
+	ipB, okB := sortableAddr(b)
+
+	switch {
+	case okA && okB:
+		if c := ipA.Compare(ipB); c != 0 {
+			return c
+		}
+		return cmp.Compare(a, b)
+	case okA:
+		return -1
+	case okB:
+		return 1
 	default:
-		return a.Lport < b.Lport
+		return cmp.Compare(a, b)
+	}
+}
+
+func sortableAddr(s string) (netip.Addr, bool) {
+	if s == "*" {
+		return netip.IPv4Unspecified(), true
+	}
+	ip, err := netip.ParseAddr(s)
+	if err != nil {
+		return netip.Addr{}, false
 	}
+	return ip.Unmap(), true
 }
 
 // stateOrder returns a numeric order for connection states
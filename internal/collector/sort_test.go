@@ -128,3 +128,131 @@ func TestSortByTimestamp(t *testing.T) {
 	}
 }
 
+func TestSortByTCPMetrics(t *testing.T) {
+	conns := []Connection{
+		{Lport: 1, SendQ: 10, RecvQ: 0, Retransmits: 2, Cwnd: 10},
+		{Lport: 2, SendQ: 4096, RecvQ: 5, Retransmits: 0, Cwnd: 1},
+		{Lport: 3, SendQ: 0, RecvQ: 900, Retransmits: 7, Cwnd: 40},
+	}
+
+	tests := []struct {
+		field SortField
+		first int
+	}{
+		{SortBySendQ, 2},
+		{SortByRecvQ, 3},
+		{SortByRetrans, 3},
+		{SortByCwnd, 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.field), func(t *testing.T) {
+			c := make([]Connection, len(conns))
+			copy(c, conns)
+
+			SortConnections(c, SortOptions{Field: tt.field, Direction: SortDesc})
+
+			if c[0].Lport != tt.first {
+				t.Errorf("expected lport %d first, got %d", tt.first, c[0].Lport)
+			}
+		})
+	}
+}
+
+func TestParseSortOptionsMultiKey(t *testing.T) {
+	opts := ParseSortOptions("state, process,lport:desc")
+	if opts.Field != SortByState || opts.Direction != SortAsc {
+		t.Errorf("primary key: got %v %v", opts.Field, opts.Direction)
+	}
+	want := []SortKey{{SortByProcess, SortAsc}, {SortByLport, SortDesc}}
+	if len(opts.Then) != len(want) {
+		t.Fatalf("expected %v, got %v", want, opts.Then)
+	}
+	for i := range want {
+		if opts.Then[i] != want[i] {
+			t.Errorf("key %d: expected %v, got %v", i, want[i], opts.Then[i])
+		}
+	}
+	if opts.String() != "state,process,lport:desc" {
+		t.Errorf("unexpected String(): %s", opts.String())
+	}
+}
+
+func TestSortMultiKey(t *testing.T) {
+	conns := []Connection{
+		{Process: "nginx", State: "ESTABLISHED", Lport: 80},
+		{Process: "sshd", State: "LISTEN", Lport: 22},
+		{Process: "nginx", State: "LISTEN", Lport: 80},
+		{Process: "nginx", State: "LISTEN", Lport: 443},
+		{Process: "curl", State: "ESTABLISHED", Lport: 51000},
+	}
+
+	SortConnections(conns, ParseSortOptions("state,process,lport:desc"))
+
+	want := []struct {
+		process string
+		lport   int
+	}{
+		{"nginx", 443},
+		{"nginx", 80},
+		{"sshd", 22},
+		{"curl", 51000},
+		{"nginx", 80},
+	}
+	for i, w := range want {
+		if conns[i].Process != w.process || conns[i].Lport != w.lport {
+			t.Errorf("position %d: expected %s:%d, got %s:%d", i, w.process, w.lport, conns[i].Process, conns[i].Lport)
+		}
+	}
+}
+
+func TestSortAddressesNumerically(t *testing.T) {
+	conns := []Connection{
+		{Raddr: "/run/docker.sock"},
+		{Raddr: "10.0.0.10"},
+		{Raddr: "2001:db8::1"},
+		{Raddr: "10.0.0.9"},
+		{Raddr: "::ffff:10.0.0.5"},
+		{Raddr: "*"},
+		{Raddr: "fe80::1%eth0"},
+		{Raddr: "9.255.255.255"},
+		{Raddr: "2001:db8::a"},
+	}
+
+	SortConnections(conns, SortOptions{Field: SortByRaddr, Direction: SortAsc})
+
+	want := []string{
+		"*",
+		"9.255.255.255",
+		"::ffff:10.0.0.5",
+		"10.0.0.9",
+		"10.0.0.10",
+		"2001:db8::1",
+		"2001:db8::a",
+		"fe80::1%eth0",
+		"/run/docker.sock",
+	}
+	for i, w := range want {
+		if conns[i].Raddr != w {
+			got := make([]string, len(conns))
+			for j, c := range conns {
+				got[j] = c.Raddr
+			}
+			t.Fatalf("expected %v, got %v", want, got)
+		}
+	}
+}
+
+func TestSortDescendingKeepsTiesStable(t *testing.T) {
+	conns := []Connection{
+		{PID: 1, Lport: 80},
+		{PID: 2, Lport: 443},
+		{PID: 3, Lport: 80},
+	}
+
+	SortConnections(conns, SortOptions{Field: SortByLport, Direction: SortDesc})
+
+	if conns[0].PID != 2 || conns[1].PID != 1 || conns[2].PID != 3 {
+		t.Errorf("expected pids [2,1,3], got [%d,%d,%d]", conns[0].PID, conns[1].PID, conns[2].PID)
+	}
+}
@@ -22,4 +22,62 @@ type Connection struct {
 	Mark       string    `json:"mark"`
 	Namespace  string    `json:"namespace"`
 	Inode      int64     `json:"inode"`
+
+	// every process holding the socket, sorted by pid. sockets inherited
+	// across fork have several; PID and Process describe the first.
+	Owners []Owner `json:"owners,omitempty"`
+
+	// where UID and User came from: the owning process, or the kernel
+	// socket table when the process could not be read. empty when unknown.
+	UIDSource string `json:"uid_source,omitempty"`
+
+	// socket type (stream, dgram, seqpacket) for unix domain sockets
+	SockType string `json:"sock_type"`
+
+	// the other end of a connected unix socket
+	PeerInode   int64  `json:"peer_inode"`
+	PeerPID     int    `json:"peer_pid"`
+	PeerProcess string `json:"peer_process"`
+
+	// owning process details beyond the command name
+	Cmdline   string    `json:"cmdline"`
+	Exe       string    `json:"exe"`
+	Cwd       string    `json:"cwd"`
+	PPID      int       `json:"ppid"`
+	TGID      int       `json:"tgid"`
+	StartTime time.Time `json:"start_time,omitzero"`
+
+	// network namespace inode, Namespace holds its friendly name if any
+	NamespaceInode int64 `json:"namespace_inode"`
+
+	// container attribution derived from the owning process's cgroup
+	ContainerRuntime string `json:"container_runtime"`
+	ContainerID      string `json:"container_id"`
+	PodUID           string `json:"pod_uid"`
+	PodName          string `json:"pod_name"`
+
+	// tcp health metrics, zero when the backend cannot provide them
+	SendQ       int    `json:"send_q"`
+	RecvQ       int    `json:"recv_q"`
+	Retransmits int    `json:"retrans"`
+	Cwnd        int    `json:"cwnd"`
+	Timer       string `json:"timer"`
+
+	// throughput in bytes per second since the previous snapshot, set by
+	// a RateTracker. zero for one-shot listings.
+	RxRate float64 `json:"rx_rate,omitempty"`
+	TxRate float64 `json:"tx_rate,omitempty"`
+}
+
+// UID sources reported in Connection.UIDSource
+const (
+	UIDSourceProcess = "process"
+	UIDSourceSocket  = "socket"
+)
+
+// Owner is a process holding a socket and the fds it holds it under
+type Owner struct {
+	PID     int    `json:"pid"`
+	Process string `json:"process"`
+	FDs     []int  `json:"fds"`
 }
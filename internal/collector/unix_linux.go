@@ -0,0 +1,274 @@
+//go:build linux
+
+package collector
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// __SO_ACCEPTCON from linux/net.h, set on listening unix sockets
+const unixFlagAcceptCon = 0x10000
+
+// unix sock_diag constants from linux/unix_diag.h
+const (
+	unixDiagReqLen   = 24  // sizeof(struct unix_diag_req)
+	unixDiagMsgLen   = 16  // sizeof(struct unix_diag_msg)
+	unixDiagPeer     = 2   // UNIX_DIAG_PEER
+	unixDiagShowPeer = 0x4 // UDIAG_SHOW_PEER
+)
+
+// unix socket types from linux/net.h
+var unixSockTypes = map[int64]string{
+	1: "stream",
+	2: "dgram",
+	5: "seqpacket",
+}
+
+// socket_state values from linux/net.h
+var unixStates = map[int64]string{
+	0: "FREE",
+	1: "UNCONNECTED",
+	2: "CONNECTING",
+	3: "CONNECTED",
+	4: "DISCONNECTING",
+}
+
+// GetUnixSockets lists unix domain sockets from /proc/net/unix in every
+// visible network namespace
+func (dc *DefaultCollector) GetUnixSockets() ([]Connection, error) {
+	report := newDiagnostics()
+	defer func() { dc.diag.add(*report) }()
+
+	inodeMap, err := buildInodeToProcessMap(report)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build inode map: %w", err)
+	}
+
+	return collectUnixSockets(inodeMap, report), nil
+}
+
+// GetUnixSockets lists unix domain sockets from /proc and pairs connected
+// ones with their peer over sock_diag. /proc has no peer information, and
+// sock_diag only sees this namespace, so sockets elsewhere stay unpaired.
+func (nc *NetlinkCollector) GetUnixSockets() ([]Connection, error) {
+	report := newDiagnostics()
+	defer func() { nc.diag.add(*report) }()
+
+	inodeMap, err := buildInodeToProcessMap(report)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build inode map: %w", err)
+	}
+
+	connections := collectUnixSockets(inodeMap, report)
+
+	peers, err := dumpUnixPeers()
+	if err != nil {
+		report.tableFailed("sock_diag unix", err)
+		return connections, nil
+	}
+	assignUnixPeers(connections, peers, inodeMap)
+
+	return connections, nil
+}
+
+func collectUnixSockets(inodeMap map[int64][]socketOwner, report *Diagnostics) []Connection {
+	namespaces, err := listNetNamespaces()
+	if err != nil {
+		path := procfs.path("net", "unix")
+		conns, err := parseProcUnix(path, inodeMap)
+		report.tableFailed(path, err)
+		processes.resolve(conns, inodeMap)
+		return conns
+	}
+
+	var connections []Connection
+	for _, ns := range namespaces {
+		path := filepath.Join(procfs.netDir(ns.pid), "unix")
+		conns, err := parseProcUnix(path, inodeMap)
+		if err != nil {
+			report.tableFailed(path, err)
+			continue
+		}
+		ns.tag(conns)
+		connections = append(connections, conns...)
+	}
+	processes.resolve(connections, inodeMap)
+
+	return connections
+}
+
+// dumpUnixPeers maps every unix socket inode in this namespace to the inode
+// of its peer
+func dumpUnixPeers() (map[int64]int64, error) {
+	fd, err := openSockDiag()
+	if err != nil {
+		return nil, err
+	}
+	defer syscall.Close(fd)
+
+	peers := make(map[int64]int64)
+	err = sockDiagDump(fd, 1, buildUnixDiagRequest(1, unixDiagShowPeer), func(data []byte) {
+		if inode, peer, ok := parseUnixDiagPeer(data); ok {
+			peers[inode] = peer
+		}
+	})
+	if err != nil {
+		return nil, fmt.Errorf("sock_diag unix dump failed: %w", err)
+	}
+
+	return peers, nil
+}
+
+// buildUnixDiagRequest encodes an nlmsghdr followed by struct unix_diag_req
+// requesting a dump of every unix socket
+func buildUnixDiagRequest(seq, show uint32) []byte {
+	buf := make([]byte, syscall.NLMSG_HDRLEN+unixDiagReqLen)
+
+	binary.NativeEndian.PutUint32(buf[0:4], uint32(len(buf)))
+	binary.NativeEndian.PutUint16(buf[4:6], sockDiagByFamily)
+	binary.NativeEndian.PutUint16(buf[6:8], syscall.NLM_F_REQUEST|syscall.NLM_F_DUMP)
+	binary.NativeEndian.PutUint32(buf[8:12], seq)
+
+	req := buf[syscall.NLMSG_HDRLEN:]
+	req[0] = syscall.AF_UNIX
+	binary.NativeEndian.PutUint32(req[4:8], inetDiagAllStates)
+	binary.NativeEndian.PutUint32(req[12:16], show)
+
+	return buf
+}
+
+// parseUnixDiagPeer reads the inode of a struct unix_diag_msg and the peer
+// inode from its UNIX_DIAG_PEER attribute
+func parseUnixDiagPeer(data []byte) (int64, int64, bool) {
+	if len(data) < unixDiagMsgLen {
+		return 0, 0, false
+	}
+	inode := int64(binary.NativeEndian.Uint32(data[4:8]))
+
+	var peer int64
+	forEachDiagAttr(data[unixDiagMsgLen:], func(attrType uint16, payload []byte) {
+		if attrType == unixDiagPeer && len(payload) >= 4 {
+			peer = int64(binary.NativeEndian.Uint32(payload[:4]))
+		}
+	})
+
+	return inode, peer, peer != 0
+}
+
+// assignUnixPeers fills the peer fields of connected sockets. the remote
+// address becomes the peer's path, which for a client is the path of the
+// listener it connected to.
+func assignUnixPeers(conns []Connection, peers map[int64]int64, inodeMap map[int64][]socketOwner) {
+	byInode := make(map[int64]int, len(conns))
+	for i := range conns {
+		byInode[conns[i].Inode] = i
+	}
+
+	for i := range conns {
+		peer, exists := peers[conns[i].Inode]
+		if !exists {
+			continue
+		}
+
+		conns[i].PeerInode = peer
+		if owners := inodeMap[peer]; len(owners) > 0 {
+			conns[i].PeerPID = owners[0].info.pid
+			conns[i].PeerProcess = owners[0].info.command
+		}
+		if j, exists := byInode[peer]; exists && conns[j].Laddr != "*" {
+			conns[i].Raddr = conns[j].Laddr
+		}
+	}
+}
+
+// parseProcUnix parses /proc/net/unix. bound sockets report their path as the
+// local address, abstract names keep the '@' the kernel shows for their
+// leading NUL, and unnamed sockets are "*".
+func parseProcUnix(path string, inodeMap map[int64][]socketOwner) ([]Connection, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var connections []Connection
+	scanner := bufio.NewScanner(file)
+
+	scanner.Scan()
+
+	for scanner.Scan() {
+		// Num RefCount Protocol Flags Type St Inode Path
+		line := scanner.Text()
+		fields := strings.Fields(line)
+		if len(fields) < 7 {
+			continue
+		}
+
+		flags, err := strconv.ParseInt(fields[3], 16, 64)
+		if err != nil {
+			continue
+		}
+		sockType, err := strconv.ParseInt(fields[4], 16, 32)
+		if err != nil {
+			continue
+		}
+		st, err := strconv.ParseInt(fields[5], 16, 32)
+		if err != nil {
+			continue
+		}
+		inode, _ := strconv.ParseInt(fields[6], 10, 64)
+
+		state := unixStates[st]
+		if flags&unixFlagAcceptCon != 0 {
+			state = "LISTEN"
+		}
+
+		conn := Connection{
+			TS:        time.Now(),
+			Proto:     "unix",
+			SockType:  unixSockTypes[sockType],
+			State:     state,
+			Laddr:     unixPath(line, fields),
+			Raddr:     "*",
+			Inode:     inode,
+			Interface: "unix",
+		}
+
+		attachProcess(&conn, inodeMap)
+
+		connections = append(connections, conn)
+	}
+
+	return connections, scanner.Err()
+}
+
+// unixPath returns everything after the inode column, so paths containing
+// spaces survive field splitting
+func unixPath(line string, fields []string) string {
+	if len(fields) < 8 {
+		return "*"
+	}
+
+	rest := line
+	for _, f := range fields[:7] {
+		i := strings.Index(rest, f)
+		if i < 0 {
+			return strings.Join(fields[7:], " ")
+		}
+		rest = rest[i+len(f):]
+	}
+
+	path := strings.TrimLeft(rest, " ")
+	if path == "" {
+		return "*"
+	}
+	return path
+}
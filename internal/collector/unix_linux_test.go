@@ -0,0 +1,107 @@
+//go:build linux
+
+package collector
+
+import (
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+)
+
+func TestParseProcUnix(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "unix")
+	content := `Num       RefCount Protocol Flags    Type St Inode Path
+0000000000000000: 00000002 00000000 00010000 0001 01 20001 /run/docker.sock
+0000000000000000: 00000003 00000000 00000000 0001 03 20002
+0000000000000000: 00000002 00000000 00000000 0002 01 20003 @/tmp/.X11-unix/X0
+0000000000000000: 00000002 00000000 00010000 0005 01 20004 /tmp/with space.sock
+`
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write fixture: %v", err)
+	}
+
+	inodeMap := map[int64][]socketOwner{20001: {{info: &processInfo{pid: 812, command: "dockerd"}}}}
+
+	conns, err := parseProcUnix(path, inodeMap)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(conns) != 4 {
+		t.Fatalf("expected 4 sockets, got %d", len(conns))
+	}
+
+	tests := []struct {
+		laddr    string
+		sockType string
+		state    string
+	}{
+		{"/run/docker.sock", "stream", "LISTEN"},
+		{"*", "stream", "CONNECTED"},
+		{"@/tmp/.X11-unix/X0", "dgram", "UNCONNECTED"},
+		{"/tmp/with space.sock", "seqpacket", "LISTEN"},
+	}
+
+	for i, tt := range tests {
+		c := conns[i]
+		if c.Laddr != tt.laddr || c.SockType != tt.sockType || c.State != tt.state {
+			t.Errorf("socket %d: expected %s %s %s, got %s %s %s", i, tt.laddr, tt.sockType, tt.state, c.Laddr, c.SockType, c.State)
+		}
+		if c.Proto != "unix" {
+			t.Errorf("socket %d: expected proto unix, got %s", i, c.Proto)
+		}
+	}
+
+	if conns[0].PID != 812 || conns[0].Process != "dockerd" {
+		t.Errorf("expected dockerd (812) to own the listener, got %s (%d)", conns[0].Process, conns[0].PID)
+	}
+}
+
+func TestParseUnixDiagPeer(t *testing.T) {
+	msg := make([]byte, unixDiagMsgLen+syscall.SizeofRtAttr+4)
+	msg[0] = syscall.AF_UNIX
+	binary.NativeEndian.PutUint32(msg[4:8], 30001)
+	attr := msg[unixDiagMsgLen:]
+	binary.NativeEndian.PutUint16(attr[0:2], syscall.SizeofRtAttr+4)
+	binary.NativeEndian.PutUint16(attr[2:4], unixDiagPeer)
+	binary.NativeEndian.PutUint32(attr[syscall.SizeofRtAttr:], 30002)
+
+	inode, peer, ok := parseUnixDiagPeer(msg)
+	if !ok || inode != 30001 || peer != 30002 {
+		t.Errorf("expected 30001 -> 30002, got %d -> %d (ok=%v)", inode, peer, ok)
+	}
+
+	if _, _, ok := parseUnixDiagPeer(msg[:unixDiagMsgLen]); ok {
+		t.Error("expected no peer without UNIX_DIAG_PEER")
+	}
+}
+
+func TestAssignUnixPeers(t *testing.T) {
+	conns := []Connection{
+		{Proto: "unix", Laddr: "/run/docker.sock", State: "LISTEN", Inode: 1},
+		{Proto: "unix", Laddr: "/run/docker.sock", State: "CONNECTED", Inode: 2},
+		{Proto: "unix", Laddr: "*", Raddr: "*", State: "CONNECTED", Inode: 3},
+	}
+	peers := map[int64]int64{2: 3, 3: 2}
+	inodeMap := map[int64][]socketOwner{
+		2: {{info: &processInfo{pid: 812, command: "dockerd"}}},
+		3: {{info: &processInfo{pid: 4100, command: "docker"}}},
+	}
+
+	assignUnixPeers(conns, peers, inodeMap)
+
+	client := conns[2]
+	if client.PeerPID != 812 || client.PeerProcess != "dockerd" || client.PeerInode != 2 {
+		t.Errorf("expected client peer dockerd (812), got %s (%d) inode %d", client.PeerProcess, client.PeerPID, client.PeerInode)
+	}
+	if client.Raddr != "/run/docker.sock" {
+		t.Errorf("expected client remote /run/docker.sock, got %s", client.Raddr)
+	}
+	if conns[1].PeerProcess != "docker" {
+		t.Errorf("expected server side peer docker, got %q", conns[1].PeerProcess)
+	}
+	if conns[0].PeerInode != 0 {
+		t.Errorf("expected listener without peer, got inode %d", conns[0].PeerInode)
+	}
+}
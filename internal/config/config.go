@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/spf13/viper"
@@ -11,7 +13,15 @@ import (
 
 // Config represents the application configuration
 type Config struct {
-	Defaults DefaultConfig `mapstructure:"defaults"`
+	Defaults DefaultConfig    `mapstructure:"defaults"`
+	Queries  map[string]Query `mapstructure:"queries"`
+}
+
+// Query is a saved filter, run as @name in place of filter arguments
+type Query struct {
+	Filter string   `mapstructure:"filter"`
+	Sort   string   `mapstructure:"sort"`
+	Fields []string `mapstructure:"fields"`
 }
 
 // DefaultConfig contains default values for CLI options
@@ -28,10 +38,16 @@ type DefaultConfig struct {
 	NoHeaders    bool     `mapstructure:"no_headers"`
 	OutputFormat string   `mapstructure:"output_format"`
 	SortBy       string   `mapstructure:"sort_by"`
+	Collector    string   `mapstructure:"collector"`
+	ProcRoot     string   `mapstructure:"proc_root"`
+	Exclude      []string `mapstructure:"exclude"`
 }
 
 var globalConfig *Config
 
+// configFile is the config file Load read, empty when none was found
+var configFile string
+
 // Load loads configuration from file and environment variables
 func Load() (*Config, error) {
 	if globalConfig != nil {
@@ -57,6 +73,7 @@ func Load() (*Config, error) {
 	_ = v.BindEnv("defaults.resolve", "SNITCH_RESOLVE")
 	_ = v.BindEnv("defaults.theme", "SNITCH_THEME")
 	_ = v.BindEnv("defaults.color", "SNITCH_NO_COLOR")
+	_ = v.BindEnv("defaults.proc_root", "SNITCH_PROC_ROOT")
 	
 	// Set defaults
 	setDefaults(v)
@@ -83,10 +100,17 @@ func Load() (*Config, error) {
 		return nil, fmt.Errorf("error unmarshaling config: %w", err)
 	}
 	
+	configFile = v.ConfigFileUsed()
 	globalConfig = config
 	return config, nil
 }
 
+// File returns the path of the config file Load read, or "" when snitch
+// runs on defaults
+func File() string {
+	return configFile
+}
+
 func setDefaults(v *viper.Viper) {
 	// Set default values matching the README specification
 	v.SetDefault("defaults.interval", "1s")
@@ -101,6 +125,9 @@ func setDefaults(v *viper.Viper) {
 	v.SetDefault("defaults.no_headers", false)
 	v.SetDefault("defaults.output_format", "table")
 	v.SetDefault("defaults.sort_by", "")
+	v.SetDefault("defaults.collector", "auto")
+	v.SetDefault("defaults.proc_root", "/proc")
+	v.SetDefault("defaults.exclude", []string{})
 }
 
 func handleSpecialEnvVars(v *viper.Viper) {
@@ -136,6 +163,8 @@ func Get() *Config {
 					NoHeaders:    false,
 					OutputFormat: "table",
 					SortBy:       "",
+					Collector:    "auto",
+					ProcRoot:     "/proc",
 				},
 			}
 		}
@@ -144,6 +173,28 @@ func Get() *Config {
 	return globalConfig
 }
 
+// Query returns the saved query called name
+func (c *Config) Query(name string) (Query, bool) {
+	// viper lowercases the keys of the queries table
+	q, ok := c.Queries[strings.ToLower(name)]
+	return q, ok
+}
+
+// QueryNames returns the names of the saved queries in alphabetical order
+func (c *Config) QueryNames() []string {
+	names := make([]string, 0, len(c.Queries))
+	for name := range c.Queries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Set replaces the global configuration, e.g. with a fixture in tests
+func Set(c *Config) {
+	globalConfig = c
+}
+
 // GetInterval returns the configured interval as a duration
 func (c *Config) GetInterval() time.Duration {
 	if duration, err := time.ParseDuration(c.Defaults.Interval); err == nil {
@@ -170,7 +221,8 @@ fields = ["pid", "process", "user", "proto", "state", "laddr", "lport", "raddr",
 # Default theme for TUI (dark, light, mono, auto)
 theme = "auto"
 
-# Default units for byte display (auto, si, iec)
+# Default units for byte counts and rates (auto, si, iec)
+# si uses powers of 1000 (kB, MB), iec and auto powers of 1024 (KiB, MiB)
 units = "auto"
 
 # Default color mode (auto, always, never)
@@ -183,10 +235,30 @@ resolve = true
 ipv4 = false
 ipv6 = false
 
-# Output options
+# Output options (output_format: table, plain, json, ndjson, csv, tsv, yaml, markdown)
 no_headers = false
 output_format = "table"
 sort_by = ""
+
+# Connection collector backend (auto, netlink, proc)
+# auto uses netlink sock_diag on linux and falls back to parsing /proc
+collector = "auto"
+
+# Where the proc filesystem is mounted (linux). point it at the host's /proc
+# mounted into a container, e.g. "/host/proc", to inspect the host
+proc_root = "/proc"
+
+# Connections to always hide: process names or filter expressions.
+# --no-exclude shows them again for one run
+exclude = []
+# exclude = ["chrome", "firefox", "state=TIME_WAIT"]
+
+# Saved queries, run as "snitch ls @db" or picked with @ in the TUI.
+# sort and fields are optional and give way to -s and -f
+# [queries.db]
+# filter = "lport in 5432,6379,3306"
+# sort = "process"
+# fields = ["process", "pid", "laddr", "lport", "raddr", "rport"]
 `
 
 	// Ensure directory exists
@@ -35,6 +35,12 @@ type ProtoStyles struct {
 	Unix lipgloss.Style
 	TCP6 lipgloss.Style
 	UDP6 lipgloss.Style
+
+	// raw and packet sockets bypass the transport layer and stand out
+	Raw    lipgloss.Style
+	Packet lipgloss.Style
+	ICMP   lipgloss.Style
+	SCTP   lipgloss.Style
 }
 
 // StateStyles contains connection state-specific colors
@@ -84,6 +90,19 @@ func GetTheme(name string) *Theme {
 	return themes["default"]
 }
 
+// ResolveName returns the name of the theme GetTheme picks for name, and
+// whether name was recognised
+func ResolveName(name string) (string, bool) {
+	if _, exists := themes[name]; exists {
+		return name, true
+	}
+	switch name {
+	case "auto", "dark", "light":
+		return "default", true
+	}
+	return "default", false
+}
+
 // ListThemes returns available theme names
 func ListThemes() []string {
 	var names []string
@@ -136,6 +155,11 @@ func createAdaptiveTheme() *Theme {
 				Unix: lipgloss.NewStyle().Foreground(lipgloss.AdaptiveColor{Light: "#6B7280", Dark: "#9CA3AF"}),
 				TCP6: lipgloss.NewStyle().Foreground(lipgloss.AdaptiveColor{Light: "#059669", Dark: "#34D399"}),
 				UDP6: lipgloss.NewStyle().Foreground(lipgloss.AdaptiveColor{Light: "#7C3AED", Dark: "#A78BFA"}),
+
+				Raw:    lipgloss.NewStyle().Foreground(lipgloss.AdaptiveColor{Light: "#DC2626", Dark: "#F87171"}),
+				Packet: lipgloss.NewStyle().Bold(true).Foreground(lipgloss.AdaptiveColor{Light: "#DC2626", Dark: "#F87171"}),
+				ICMP:   lipgloss.NewStyle().Foreground(lipgloss.AdaptiveColor{Light: "#D97706", Dark: "#FBBF24"}),
+				SCTP:   lipgloss.NewStyle().Foreground(lipgloss.AdaptiveColor{Light: "#2563EB", Dark: "#60A5FA"}),
 			},
 
 			State: StateStyles{
@@ -179,6 +203,11 @@ func createMonoTheme() *Theme {
 				Unix: baseStyle,
 				TCP6: baseStyle,
 				UDP6: baseStyle,
+
+				Raw:    boldStyle,
+				Packet: boldStyle,
+				ICMP:   baseStyle,
+				SCTP:   baseStyle,
 			},
 
 			State: StateStyles{
@@ -203,7 +232,7 @@ func (s *Styles) GetProtoStyle(proto string) lipgloss.Style {
 	switch strings.ToLower(proto) {
 	case "tcp":
 		return s.Proto.TCP
-	case "udp":
+	case "udp", "udplite", "udplite6":
 		return s.Proto.UDP
 	case "unix":
 		return s.Proto.Unix
@@ -211,6 +240,14 @@ func (s *Styles) GetProtoStyle(proto string) lipgloss.Style {
 		return s.Proto.TCP6
 	case "udp6":
 		return s.Proto.UDP6
+	case "raw", "raw6":
+		return s.Proto.Raw
+	case "packet":
+		return s.Proto.Packet
+	case "icmp", "icmp6":
+		return s.Proto.ICMP
+	case "sctp":
+		return s.Proto.SCTP
 	default:
 		return s.Normal
 	}
@@ -3,10 +3,13 @@ package tui
 import (
 	"fmt"
 	"regexp"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/karol-broda/snitch/internal/collector"
 	"github.com/karol-broda/snitch/internal/geoip"
+	"github.com/karol-broda/snitch/internal/units"
 )
 
 func truncate(s string, max int) string {
@@ -35,15 +38,123 @@ func sortFieldLabel(f collector.SortField) string {
 		return "port"
 	case collector.SortByProcess:
 		return "proc"
-	case collector.SortByPID:
-		return "pid"
-	case collector.SortByState:
-		return "state"
-	case collector.SortByProto:
-		return "proto"
-	default:
+	case collector.SortByRport:
+		return "rport"
+	case collector.SortByTimestamp:
+		return "time"
+	case "":
 		return "port"
+	default:
+		return string(f)
+	}
+}
+
+// formatNamespace shows the namespace name with its inode, or just the inode when unnamed
+func formatNamespace(c collector.Connection) string {
+	if c.NamespaceInode == 0 {
+		return c.Namespace
+	}
+	if c.Namespace == "" {
+		return fmt.Sprintf("%d", c.NamespaceInode)
+	}
+	return fmt.Sprintf("%s (%d)", c.Namespace, c.NamespaceInode)
+}
+
+// formatContainer shows the runtime and short container id
+func formatContainer(c collector.Connection) string {
+	id := c.ContainerID
+	if len(id) > 12 {
+		id = id[:12]
+	}
+	return strings.TrimSpace(c.ContainerRuntime + " " + id)
+}
+
+// formatPod shows the pod name with its uid, or just the uid when the name is unknown
+func formatPod(c collector.Connection) string {
+	if c.PodUID == "" {
+		return ""
+	}
+	if c.PodName == "" {
+		return c.PodUID
+	}
+	return fmt.Sprintf("%s (%s)", c.PodName, c.PodUID)
+}
+
+// formatUser shows the socket's user, noting when only the kernel socket
+// table knew it because the owning process could not be read
+func formatUser(c collector.Connection) string {
+	if c.UIDSource == collector.UIDSourceSocket {
+		return fmt.Sprintf("%s (uid %d, from socket table)", c.User, c.UID)
+	}
+	return c.User
+}
+
+// formatStartTime shows when the owning process started and how long ago
+func formatStartTime(c collector.Connection) string {
+	if c.StartTime.IsZero() {
+		return ""
+	}
+	age := time.Since(c.StartTime).Truncate(time.Second)
+	return fmt.Sprintf("%s (%s ago)", c.StartTime.Format("2006-01-02 15:04:05"), age)
+}
+
+// formatProcess shows the owning process within max columns, with the count
+// of other processes sharing the socket kept visible, e.g. "nginx (+7)"
+func formatProcess(c collector.Connection, max int) string {
+	if len(c.Owners) <= 1 || c.Process == "" {
+		return truncate(c.Process, max)
+	}
+	suffix := fmt.Sprintf(" (+%d)", len(c.Owners)-1)
+	if len(suffix) >= max {
+		return truncate(c.Process, max)
+	}
+	return truncate(c.Process, max-len(suffix)) + suffix
+}
+
+// formatOwner shows one process holding a socket with the fds it uses
+func formatOwner(o collector.Owner) string {
+	fds := make([]string, len(o.FDs))
+	for i, fd := range o.FDs {
+		fds[i] = strconv.Itoa(fd)
+	}
+	return fmt.Sprintf("%s (%d) fd %s", o.Process, o.PID, strings.Join(fds, ","))
+}
+
+// formatPeer shows the process on the other end of a unix socket with its
+// pid, or the peer inode when the owner is not visible
+func formatPeer(c collector.Connection) string {
+	if c.PeerProcess != "" {
+		return fmt.Sprintf("%s (%d)", c.PeerProcess, c.PeerPID)
+	}
+	if c.PeerInode != 0 {
+		return fmt.Sprintf("inode %d", c.PeerInode)
+	}
+	return ""
+}
+
+// formatBytesRate shows a byte count with the throughput since the last
+// refresh, e.g. "1.5MiB (12.0KiB/s)"
+func formatBytesRate(bytes int64, rate float64, mode string) string {
+	if bytes == 0 && rate == 0 {
+		return ""
 	}
+	return fmt.Sprintf("%s (%s)", units.FormatBytes(bytes, mode), units.FormatRate(rate, mode))
+}
+
+// formatRate shows a rate column, a dash when nothing moved
+func (m model) formatRate(rate float64) string {
+	if rate == 0 {
+		return SymbolDash
+	}
+	return units.FormatRate(rate, m.units)
+}
+
+// formatEndpoint joins an address and port, unix sockets only have a path
+func formatEndpoint(proto, addr string, port int) string {
+	if proto == "unix" {
+		return addr
+	}
+	return fmt.Sprintf("%s:%d", addr, port)
 }
 
 func formatRemote(addr string, port int) string {
@@ -19,6 +19,16 @@ func (m model) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		return m.handleKillConfirmKey(msg)
 	}
 
+	// sort picker
+	if m.showSortPicker {
+		return m.handleSortPickerKey(msg)
+	}
+
+	// saved query picker
+	if m.showQueryPicker {
+		return m.handleQueryPickerKey(msg)
+	}
+
 	// detail view only allows closing
 	if m.showDetail {
 		return m.handleDetailKey(msg)
@@ -88,6 +98,55 @@ func (m model) handleKillConfirmKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
+func (m model) handleSortPickerKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	fields := collector.SortFields
+	switch msg.String() {
+	case "j", "down":
+		m.sortPickerCursor = (m.sortPickerCursor + 1) % len(fields)
+	case "k", "up":
+		m.sortPickerCursor = (m.sortPickerCursor + len(fields) - 1) % len(fields)
+	case "g":
+		m.sortPickerCursor = 0
+	case "G":
+		m.sortPickerCursor = len(fields) - 1
+	case "enter", " ":
+		m.sortField = fields[m.sortPickerCursor]
+		m.sortThen = nil
+		m.showSortPicker = false
+		m.applySorting()
+	case "S":
+		m.sortReverse = !m.sortReverse
+		m.applySorting()
+	case "esc", "q", ">":
+		m.showSortPicker = false
+	}
+	return m, nil
+}
+
+func (m model) handleQueryPickerKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	n := len(m.queries)
+	switch msg.String() {
+	case "j", "down":
+		m.queryPickerCursor = (m.queryPickerCursor + 1) % n
+	case "k", "up":
+		m.queryPickerCursor = (m.queryPickerCursor + n - 1) % n
+	case "g":
+		m.queryPickerCursor = 0
+	case "G":
+		m.queryPickerCursor = n - 1
+	case "enter", " ":
+		q := m.queries[m.queryPickerCursor]
+		m.showQueryPicker = false
+		m.applyQuery(q)
+		m.statusMessage = fmt.Sprintf("query @%s", q.Name)
+		m.statusExpiry = time.Now().Add(2 * time.Second)
+		return m, clearStatusAfter(2 * time.Second)
+	case "esc", "q", "@":
+		m.showQueryPicker = false
+	}
+	return m, nil
+}
+
 func (m model) handleNormalKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	switch msg.String() {
 	case "q", "ctrl+c":
@@ -121,6 +180,22 @@ func (m model) handleNormalKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	case "u":
 		m.showUDP = !m.showUDP
 		m.clampCursor()
+	case "R":
+		m.showRaw = !m.showRaw
+		m.clampCursor()
+	case "i":
+		m.showICMP = !m.showICMP
+		m.clampCursor()
+	case "c":
+		m.showSCTP = !m.showSCTP
+		m.clampCursor()
+	case "p":
+		m.showPacket = !m.showPacket
+		m.clampCursor()
+	case "x":
+		m.showUnix = !m.showUnix
+		m.clampCursor()
+		return m, m.fetchData()
 	case "l":
 		m.showListening = !m.showListening
 		m.clampCursor()
@@ -133,6 +208,10 @@ func (m model) handleNormalKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	case "a":
 		m.showTCP = true
 		m.showUDP = true
+		m.showRaw = true
+		m.showICMP = true
+		m.showSCTP = true
+		m.showPacket = true
 		m.showListening = true
 		m.showEstablished = true
 		m.showOther = true
@@ -143,6 +222,24 @@ func (m model) handleNormalKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	case "S":
 		m.sortReverse = !m.sortReverse
 		m.applySorting()
+	case ">":
+		m.showSortPicker = true
+		m.sortPickerCursor = 0
+		for i, f := range collector.SortFields {
+			if f == m.sortField {
+				m.sortPickerCursor = i
+			}
+		}
+
+	// saved queries
+	case "@":
+		if len(m.queries) == 0 {
+			m.statusMessage = "no saved queries in the config file"
+			m.statusExpiry = time.Now().Add(2 * time.Second)
+			return m, clearStatusAfter(2 * time.Second)
+		}
+		m.showQueryPicker = true
+		m.queryPickerCursor = 0
 
 	// search
 	case "/":
@@ -238,13 +335,8 @@ func (m model) pageSize() int {
 }
 
 func (m *model) cycleSort() {
-	fields := []collector.SortField{
-		collector.SortByLport,
-		collector.SortByProcess,
-		collector.SortByPID,
-		collector.SortByState,
-		collector.SortByProto,
-	}
+	fields := collector.SortFields
+	m.sortThen = nil
 
 	for i, f := range fields {
 		if f == m.sortField {
@@ -13,6 +13,8 @@ type tickMsg time.Time
 
 type dataMsg struct {
 	connections []collector.Connection
+	warning     string
+	collected   time.Time
 }
 
 type errMsg struct {
@@ -34,13 +36,20 @@ func (m model) tick() tea.Cmd {
 	})
 }
 
+// fetchData collects connections, including unix sockets only while they
+// are shown since there are usually far more of them than network sockets
 func (m model) fetchData() tea.Cmd {
+	fetch := collector.GetConnections
+	if m.showUnix {
+		fetch = collector.GetAllConnections
+	}
+
 	return func() tea.Msg {
-		conns, err := collector.GetConnections()
+		conns, err := fetch()
 		if err != nil {
 			return errMsg{err}
 		}
-		return dataMsg{connections: conns}
+		return dataMsg{connections: conns, warning: collector.GetDiagnostics().Warning(), collected: time.Now()}
 	}
 }
 
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"github.com/karol-broda/snitch/internal/collector"
 	"github.com/karol-broda/snitch/internal/theme"
+	"strings"
 	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
@@ -18,15 +19,32 @@ type model struct {
 	// filtering
 	showTCP         bool
 	showUDP         bool
+	showRaw         bool
+	showICMP        bool
+	showSCTP        bool
+	showPacket      bool
+	showUnix        bool
 	showListening   bool
 	showEstablished bool
 	showOther       bool
 	searchQuery     string
 	searchActive    bool
 
-	// sorting
-	sortField   collector.SortField
-	sortReverse bool
+	// hidden connections, from --exclude and the config file
+	exclude []collector.Expr
+
+	// saved queries from the config file
+	queries           []SavedQuery
+	showQueryPicker   bool
+	queryPickerCursor int
+
+	// sorting. sortThen holds the tie-breaking keys of a saved query's sort
+	// until another field is picked
+	sortField        collector.SortField
+	sortReverse      bool
+	sortThen         []collector.SortKey
+	showSortPicker   bool
+	sortPickerCursor int
 
 	// ui state
 	theme       *theme.Theme
@@ -47,6 +65,13 @@ type model struct {
 	// status message (temporary feedback)
 	statusMessage string
 	statusExpiry  time.Time
+
+	// what the last collection could not see, e.g. unreadable processes
+	warning string
+
+	// throughput between refreshes, shown in the units of the config file
+	rates *collector.RateTracker
+	units string
 }
 
 type Options struct {
@@ -54,10 +79,28 @@ type Options struct {
 	Interval    time.Duration
 	TCP         bool
 	UDP         bool
+	Unix        bool
 	Listening   bool
 	Established bool
 	Other       bool
 	FilterSet   bool // true if user specified any filter flags
+
+	// connections matching any of these are never shown
+	Exclude []collector.Expr
+
+	// saved queries, picked with @
+	Queries []SavedQuery
+
+	// units of byte counts and rates: auto, si or iec
+	Units string
+}
+
+// SavedQuery is a named filter from the config file. picking it makes
+// Filter the search query and Sort, if set, the sort order.
+type SavedQuery struct {
+	Name   string
+	Filter string
+	Sort   string
 }
 
 func New(opts Options) model {
@@ -69,6 +112,8 @@ func New(opts Options) model {
 	// default: show everything
 	showTCP := true
 	showUDP := true
+	showExtra := true
+	showUnix := false
 	showListening := true
 	showEstablished := true
 	showOther := true
@@ -87,10 +132,16 @@ func New(opts Options) model {
 			showEstablished = true
 			showOther = true
 		}
-		// if only state filters set, show all protos
-		if !opts.TCP && !opts.UDP {
+		showUnix = opts.Unix
+
+		// if only state filters set, show all network protos. protocols
+		// without a flag of their own are hidden once -t, -u or -x narrows
+		// the view
+		if !opts.TCP && !opts.UDP && !opts.Unix {
 			showTCP = true
 			showUDP = true
+		} else {
+			showExtra = false
 		}
 	}
 
@@ -98,14 +149,23 @@ func New(opts Options) model {
 		connections:     []collector.Connection{},
 		showTCP:         showTCP,
 		showUDP:         showUDP,
+		showRaw:         showExtra,
+		showICMP:        showExtra,
+		showSCTP:        showExtra,
+		showPacket:      showExtra,
+		showUnix:        showUnix,
 		showListening:   showListening,
 		showEstablished: showEstablished,
 		showOther:       showOther,
+		exclude:         opts.Exclude,
+		queries:         opts.Queries,
 		sortField:       collector.SortByLport,
 		theme:           theme.GetTheme(opts.Theme),
 		interval:        interval,
 		lastRefresh:     time.Now(),
 		watchedPIDs:     make(map[int]bool),
+		rates:           collector.NewRateTracker(),
+		units:           opts.Units,
 	}
 }
 
@@ -131,7 +191,9 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m, tea.Batch(m.fetchData(), m.tick())
 
 	case dataMsg:
+		m.rates.Update(msg.connections, msg.collected)
 		m.connections = msg.connections
+		m.warning = msg.warning
 		m.lastRefresh = time.Now()
 		m.applySorting()
 		m.clampCursor()
@@ -177,6 +239,12 @@ func (m model) View() string {
 	if m.showKillConfirm && m.killTarget != nil {
 		return m.overlayModal(main, m.renderKillModal())
 	}
+	if m.showSortPicker {
+		return m.overlayModal(main, m.renderSortPicker())
+	}
+	if m.showQueryPicker {
+		return m.overlayModal(main, m.renderQueryPicker())
+	}
 
 	return main
 }
@@ -189,9 +257,23 @@ func (m *model) applySorting() {
 	collector.SortConnections(m.connections, collector.SortOptions{
 		Field:     m.sortField,
 		Direction: direction,
+		Then:      m.sortThen,
 	})
 }
 
+// applyQuery filters and sorts the view by a saved query
+func (m *model) applyQuery(q SavedQuery) {
+	m.searchQuery = q.Filter
+	m.cursor = 0
+	if q.Sort != "" {
+		opts := collector.ParseSortOptions(q.Sort)
+		m.sortField = opts.Field
+		m.sortReverse = opts.Direction == collector.SortDesc
+		m.sortThen = opts.Then
+		m.applySorting()
+	}
+}
+
 func (m *model) clampCursor() {
 	visible := m.visibleConnections()
 	if m.cursor >= len(visible) {
@@ -206,11 +288,12 @@ func (m model) visibleConnections() []collector.Connection {
 	var watched []collector.Connection
 	var unwatched []collector.Connection
 
+	search := m.searchMatcher()
 	for _, c := range m.connections {
 		if !m.matchesFilters(c) {
 			continue
 		}
-		if m.searchQuery != "" && !m.matchesSearch(c) {
+		if m.searchQuery != "" && !search(c) {
 			continue
 		}
 		if m.isWatched(c.PID) {
@@ -226,7 +309,9 @@ func (m model) visibleConnections() []collector.Connection {
 
 func (m model) matchesFilters(c collector.Connection) bool {
 	isTCP := c.Proto == "tcp" || c.Proto == "tcp6"
-	isUDP := c.Proto == "udp" || c.Proto == "udp6"
+	isUDP := strings.HasPrefix(c.Proto, "udp")
+	isRaw := c.Proto == "raw" || c.Proto == "raw6"
+	isICMP := c.Proto == "icmp" || c.Proto == "icmp6"
 
 	if isTCP && !m.showTCP {
 		return false
@@ -234,6 +319,27 @@ func (m model) matchesFilters(c collector.Connection) bool {
 	if isUDP && !m.showUDP {
 		return false
 	}
+	if isRaw && !m.showRaw {
+		return false
+	}
+	if isICMP && !m.showICMP {
+		return false
+	}
+	if c.Proto == "sctp" && !m.showSCTP {
+		return false
+	}
+	if c.Proto == "packet" && !m.showPacket {
+		return false
+	}
+	if c.Proto == "unix" && !m.showUnix {
+		return false
+	}
+
+	for _, exclude := range m.exclude {
+		if exclude.Match(c) {
+			return false
+		}
+	}
 
 	isListening := c.State == "LISTEN"
 	isEstablished := c.State == "ESTABLISHED"
@@ -252,8 +358,19 @@ func (m model) matchesFilters(c collector.Connection) bool {
 	return true
 }
 
+// searchMatcher treats the search query as a filter expression when it
+// parses as one, e.g. "lport<1024 or proc=ssh", and as a substring otherwise
+func (m model) searchMatcher() func(collector.Connection) bool {
+	filter, err := collector.ParseFilter(m.searchQuery)
+	if err != nil || filter.IsEmpty() {
+		return m.matchesSearch
+	}
+	return filter.Matches
+}
+
 func (m model) matchesSearch(c collector.Connection) bool {
 	return containsIgnoreCase(c.Process, m.searchQuery) ||
+		containsIgnoreCase(c.Cmdline, m.searchQuery) ||
 		containsIgnoreCase(c.Laddr, m.searchQuery) ||
 		containsIgnoreCase(c.Raddr, m.searchQuery) ||
 		containsIgnoreCase(c.User, m.searchQuery) ||
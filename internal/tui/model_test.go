@@ -1,7 +1,9 @@
 package tui
 
 import (
+	"fmt"
 	"github.com/karol-broda/snitch/internal/collector"
+	"strings"
 	"testing"
 	"time"
 
@@ -27,6 +29,25 @@ func TestTUI_InitialState(t *testing.T) {
 	if m.showEstablished != true {
 		t.Error("expected showEstablished to be true by default")
 	}
+	if m.showUnix != false {
+		t.Error("expected showUnix to be false by default")
+	}
+}
+
+func TestTUI_UnixFilterOption(t *testing.T) {
+	m := New(Options{
+		Theme:     "dark",
+		Interval:  time.Second,
+		Unix:      true,
+		FilterSet: true,
+	})
+
+	if !m.matchesFilters(collector.Connection{Proto: "unix", State: "LISTEN"}) {
+		t.Error("expected unix sockets to be shown with -x")
+	}
+	if m.matchesFilters(collector.Connection{Proto: "tcp", State: "LISTEN"}) {
+		t.Error("expected tcp to be hidden with -x")
+	}
 }
 
 func TestTUI_FilterOptions(t *testing.T) {
@@ -135,6 +156,183 @@ func TestTUI_MatchesSearch(t *testing.T) {
 	}
 }
 
+func TestTUI_SearchExpression(t *testing.T) {
+	m := New(Options{Theme: "dark"})
+	m.connections = []collector.Connection{
+		{Process: "sshd", Proto: "tcp", State: "LISTEN", Lport: 22},
+		{Process: "nginx", Proto: "tcp", State: "LISTEN", Lport: 8080},
+		{Process: "lport-proxy", Proto: "tcp", State: "LISTEN", Lport: 9000},
+	}
+
+	tests := []struct {
+		query    string
+		expected []string
+	}{
+		{"lport<1024 or proc=nginx", []string{"sshd", "nginx"}},
+		{"not proc=nginx", []string{"sshd", "lport-proxy"}},
+		{"lport", []string{"lport-proxy"}},
+		{"lport<", []string{}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.query, func(t *testing.T) {
+			m.searchQuery = tc.query
+			visible := m.visibleConnections()
+			if len(visible) != len(tc.expected) {
+				t.Fatalf("expected %v, got %d connections", tc.expected, len(visible))
+			}
+			for i, c := range visible {
+				if c.Process != tc.expected[i] {
+					t.Errorf("expected %v, got %s at %d", tc.expected, c.Process, i)
+				}
+			}
+		})
+	}
+}
+
+func TestTUI_SortPicker(t *testing.T) {
+	m := New(Options{Theme: "dark"})
+	m.width = 120
+	m.height = 40
+
+	seen := map[collector.SortField]bool{m.sortField: true}
+	for range collector.SortFields {
+		m.cycleSort()
+		seen[m.sortField] = true
+	}
+	if len(seen) != len(collector.SortFields) {
+		t.Errorf("cycleSort visited %d of %d fields", len(seen), len(collector.SortFields))
+	}
+	if m.sortField != collector.SortByLport {
+		t.Errorf("expected the cycle to wrap around to lport, got %s", m.sortField)
+	}
+
+	key := func(s string) tea.KeyMsg {
+		switch s {
+		case "enter":
+			return tea.KeyMsg{Type: tea.KeyEnter}
+		case "esc":
+			return tea.KeyMsg{Type: tea.KeyEsc}
+		}
+		return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(s)}
+	}
+
+	next, _ := m.handleKey(key(">"))
+	m = next.(model)
+	if !m.showSortPicker {
+		t.Fatal("expected > to open the sort picker")
+	}
+	if !strings.Contains(m.View(), "SORT BY") {
+		t.Error("expected the sort picker in the view")
+	}
+
+	for _, k := range []string{"j", "j", "enter"} {
+		next, _ = m.handleKey(key(k))
+		m = next.(model)
+	}
+	if m.showSortPicker {
+		t.Error("expected enter to close the sort picker")
+	}
+	if m.sortField != collector.SortFields[2] {
+		t.Errorf("expected %s, got %s", collector.SortFields[2], m.sortField)
+	}
+
+	next, _ = m.handleKey(key(">"))
+	m = next.(model)
+	next, _ = m.handleKey(key("esc"))
+	m = next.(model)
+	if m.showSortPicker || m.sortField != collector.SortFields[2] {
+		t.Error("expected esc to close the picker without changing the sort")
+	}
+}
+
+func TestTUI_QueryPicker(t *testing.T) {
+	m := New(Options{Theme: "dark", Queries: []SavedQuery{
+		{Name: "db", Filter: "lport in 5432,6379", Sort: "process:desc"},
+		{Name: "web", Filter: "lport=443"},
+	}})
+	m.width = 120
+	m.height = 40
+	m.connections = []collector.Connection{
+		{Process: "nginx", Proto: "tcp", State: "LISTEN", Lport: 443},
+		{Process: "postgres", Proto: "tcp", State: "LISTEN", Lport: 5432},
+		{Process: "redis", Proto: "tcp", State: "LISTEN", Lport: 6379},
+	}
+
+	key := func(s string) tea.KeyMsg {
+		switch s {
+		case "enter":
+			return tea.KeyMsg{Type: tea.KeyEnter}
+		case "esc":
+			return tea.KeyMsg{Type: tea.KeyEsc}
+		}
+		return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(s)}
+	}
+
+	next, _ := m.handleKey(key("@"))
+	m = next.(model)
+	if !m.showQueryPicker {
+		t.Fatal("expected @ to open the query picker")
+	}
+	if !strings.Contains(m.View(), "@web") {
+		t.Error("expected the saved queries in the view")
+	}
+
+	next, _ = m.handleKey(key("enter"))
+	m = next.(model)
+	if m.showQueryPicker {
+		t.Error("expected enter to close the query picker")
+	}
+	if m.searchQuery != "lport in 5432,6379" {
+		t.Errorf("expected the query filter as search, got %q", m.searchQuery)
+	}
+	if m.sortField != collector.SortByProcess || !m.sortReverse {
+		t.Errorf("expected the query sort process:desc, got %s reverse=%t", m.sortField, m.sortReverse)
+	}
+
+	visible := m.visibleConnections()
+	if len(visible) != 2 || visible[0].Process != "redis" || visible[1].Process != "postgres" {
+		t.Errorf("expected redis and postgres, got %v", visible)
+	}
+
+	empty := New(Options{Theme: "dark"})
+	next, _ = empty.handleKey(key("@"))
+	if next.(model).showQueryPicker {
+		t.Error("expected no query picker without saved queries")
+	}
+}
+
+func TestTUI_QueryMultiKeySort(t *testing.T) {
+	m := New(Options{Theme: "dark"})
+	m.width = 120
+	m.height = 40
+	m.connections = []collector.Connection{
+		{Process: "sshd", Proto: "tcp", State: "LISTEN", Lport: 22},
+		{Process: "nginx", Proto: "tcp", State: "ESTABLISHED", Lport: 443},
+		{Process: "nginx", Proto: "tcp", State: "LISTEN", Lport: 80},
+		{Process: "curl", Proto: "tcp", State: "ESTABLISHED", Lport: 51000},
+	}
+
+	m.applyQuery(SavedQuery{Name: "by-state", Sort: "state,lport:desc"})
+
+	var ports []int
+	for _, c := range m.visibleConnections() {
+		ports = append(ports, c.Lport)
+	}
+	if fmt.Sprint(ports) != "[80 22 51000 443]" {
+		t.Errorf("expected the secondary key to break ties like ls, got %v", ports)
+	}
+	if !strings.Contains(m.View(), "port "+SymbolArrowDown) {
+		t.Error("expected the secondary sort key in the status line")
+	}
+
+	// picking another field drops the query's tie-breakers
+	m.cycleSort()
+	if m.sortThen != nil {
+		t.Errorf("expected no secondary keys after cycling, got %v", m.sortThen)
+	}
+}
+
 func TestTUI_KeyBindings(t *testing.T) {
 	tm := teatest.NewTestModel(t, New(Options{Theme: "dark", Interval: time.Hour}))
 
@@ -301,3 +499,84 @@ func TestTUI_ViewRenders(t *testing.T) {
 	}
 }
 
+func TestTUI_SharedSocketOwners(t *testing.T) {
+	m := New(Options{Theme: "dark", Interval: time.Hour})
+	m.width = 120
+	m.height = 40
+
+	shared := collector.Connection{
+		PID: 300, Process: "nginx", Proto: "tcp", State: "LISTEN", Laddr: "0.0.0.0", Lport: 80,
+		Owners: []collector.Owner{
+			{PID: 300, Process: "nginx", FDs: []int{6, 9}},
+			{PID: 301, Process: "nginx", FDs: []int{6}},
+			{PID: 302, Process: "nginx", FDs: []int{6}},
+		},
+	}
+	m.connections = []collector.Connection{shared}
+
+	if row := m.renderRow(shared, false); !strings.Contains(row, "nginx (+2)") {
+		t.Errorf("expected row to show nginx (+2), got %q", row)
+	}
+
+	m.selected = &shared
+	detail := m.renderDetail()
+	for _, want := range []string{"nginx (300) fd 6,9", "nginx (301) fd 6", "nginx (302) fd 6"} {
+		if !strings.Contains(detail, want) {
+			t.Errorf("expected detail view to list %q", want)
+		}
+	}
+
+	if got := formatProcess(shared, 8); got != "ng… (+2)" {
+		t.Errorf("expected truncated name to keep the owner count, got %q", got)
+	}
+}
+
+func TestTUI_DiagnosticsWarning(t *testing.T) {
+	m := New(Options{Theme: "dark", Interval: time.Hour})
+	m.width = 160
+	m.height = 40
+
+	updated, _ := m.Update(dataMsg{warning: "57 of 60 processes not accessible"})
+	m = updated.(model)
+
+	if status := m.renderStatusLine(); !strings.Contains(status, "57 of 60 processes not accessible") {
+		t.Errorf("expected warning in status line, got %q", status)
+	}
+
+	updated, _ = m.Update(dataMsg{})
+	m = updated.(model)
+	if m.warning != "" {
+		t.Errorf("expected warning to clear after a clean collection, got %q", m.warning)
+	}
+}
+
+func TestTUI_Rates(t *testing.T) {
+	m := New(Options{Theme: "dark", Interval: time.Hour, Units: "si"})
+	m.width = 160
+	m.height = 40
+
+	start := time.Unix(1700000000, 0)
+	conn := collector.Connection{Process: "curl", Proto: "tcp", State: "ESTABLISHED", Laddr: "10.0.0.1", Lport: 40000, Raddr: "1.1.1.1", Rport: 443, Inode: 7}
+
+	first := conn
+	first.RxBytes = 1000
+	updated, _ := m.Update(dataMsg{connections: []collector.Connection{first}, collected: start})
+	m = updated.(model)
+
+	second := conn
+	second.RxBytes = 3000
+	updated, _ = m.Update(dataMsg{connections: []collector.Connection{second}, collected: start.Add(time.Second)})
+	m = updated.(model)
+
+	if header := m.renderTableHeader(); !strings.Contains(header, "RX/S") || !strings.Contains(header, "TX/S") {
+		t.Errorf("expected rate columns on a wide terminal, got %q", header)
+	}
+	if row := m.renderRow(m.connections[0], false); !strings.Contains(row, "2.0kB/s") {
+		t.Errorf("expected rx rate in si units, got %q", row)
+	}
+
+	m.width = 80
+	if header := m.renderTableHeader(); strings.Contains(header, "RX/S") {
+		t.Errorf("expected rate columns to be hidden on a narrow terminal, got %q", header)
+	}
+}
@@ -49,6 +49,11 @@ func (m model) renderFilters() string {
 
 	parts = append(parts, m.renderFilterLabel("t", "cp", m.showTCP))
 	parts = append(parts, m.renderFilterLabel("u", "dp", m.showUDP))
+	parts = append(parts, m.renderFilterLabel("R", "aw", m.showRaw))
+	parts = append(parts, m.renderFilterLabel("i", "cmp", m.showICMP))
+	parts = append(parts, m.renderFilterLabelAt("s", "c", "tp", m.showSCTP))
+	parts = append(parts, m.renderFilterLabel("p", "acket", m.showPacket))
+	parts = append(parts, m.renderFilterLabelAt("uni", "x", "", m.showUnix))
 
 	parts = append(parts, m.theme.Styles.Border.Render(BoxVertical))
 
@@ -63,6 +68,13 @@ func (m model) renderFilters() string {
 	if m.sortReverse {
 		sortDir = SymbolArrowDown
 	}
+	for _, key := range m.sortThen {
+		dir := SymbolArrowUp
+		if key.Direction == collector.SortDesc {
+			dir = SymbolArrowDown
+		}
+		sortDir += ", " + sortFieldLabel(key.Field) + " " + dir
+	}
 
 	var right string
 	if m.searchActive {
@@ -85,13 +97,19 @@ func (m model) renderFilters() string {
 func (m model) renderTableHeader() string {
 	cols := m.columnWidths()
 
-	header := fmt.Sprintf("  %-*s  %-*s  %-*s  %-*s  %-*s  %-*s  %-*s  %s",
+	rates := ""
+	if cols.rate > 0 {
+		rates = fmt.Sprintf("%*s  %*s  ", cols.rate, "RX/S", cols.rate, "TX/S")
+	}
+
+	header := fmt.Sprintf("  %-*s  %-*s  %-*s  %-*s  %-*s  %-*s  %s%-*s  %s",
 		cols.process, "PROCESS",
 		cols.port, "PORT",
 		cols.proto, "PROTO",
 		cols.state, "STATE",
 		cols.local, "LOCAL",
 		cols.remote, "REMOTE",
+		rates,
 		cols.country, "CTRY",
 		"ORG")
 
@@ -99,15 +117,22 @@ func (m model) renderTableHeader() string {
 }
 
 func (m model) renderFilterLabel(firstChar, rest string, active bool) string {
+	return m.renderFilterLabelAt("", firstChar, rest, active)
+}
+
+// renderFilterLabelAt underlines the toggle key inside a label, for labels
+// whose first letter is already bound to another key
+func (m model) renderFilterLabelAt(before, key, after string, active bool) string {
 	baseStyle := m.theme.Styles.Normal
 	if active {
 		baseStyle = m.theme.Styles.Success
 	}
 
-	underlinedFirst := baseStyle.Underline(true).Render(firstChar)
-	restPart := baseStyle.Render(rest)
-
-	return underlinedFirst + restPart
+	label := baseStyle.Underline(true).Render(key) + baseStyle.Render(after)
+	if before != "" {
+		label = baseStyle.Render(before) + label
+	}
+	return label
 }
 
 func (m model) renderSeparator() string {
@@ -158,12 +183,15 @@ func (m model) renderRow(c collector.Connection, selected bool) string {
 		indicator = m.theme.Styles.Watched.Render(SymbolWatched + " ")
 	}
 
-	process := truncate(c.Process, cols.process)
+	process := formatProcess(c, cols.process)
 	if process == "" {
 		process = SymbolDash
 	}
 
 	port := fmt.Sprintf("%d", c.Lport)
+	if c.Proto == "unix" {
+		port = SymbolDash
+	}
 	proto := c.Proto
 	state := c.State
 	if state == "" {
@@ -176,6 +204,9 @@ func (m model) renderRow(c collector.Connection, selected bool) string {
 	}
 
 	remote := formatRemote(c.Raddr, c.Rport)
+	if c.Proto == "unix" && c.PeerProcess != "" {
+		remote = c.PeerProcess
+	}
 
 	// Get flag and org for non-local IP
 	flag := getConnectionFlag(c)
@@ -188,7 +219,14 @@ func (m model) renderRow(c collector.Connection, selected bool) string {
 	protoStyled := m.theme.Styles.GetProtoStyle(proto).Render(fmt.Sprintf("%-*s", cols.proto, proto))
 	stateStyled := m.theme.Styles.GetStateStyle(state).Render(fmt.Sprintf("%-*s", cols.state, truncate(state, cols.state)))
 
-	row := fmt.Sprintf("%s%-*s  %-*s  %s  %s  %-*s  %-*s  %-*s  %s",
+	rates := ""
+	if cols.rate > 0 {
+		rates = fmt.Sprintf("%*s  %*s  ",
+			cols.rate, m.formatRate(c.RxRate),
+			cols.rate, m.formatRate(c.TxRate))
+	}
+
+	row := fmt.Sprintf("%s%-*s  %-*s  %s  %s  %-*s  %-*s  %s%-*s  %s",
 		indicator,
 		cols.process, process,
 		cols.port, port,
@@ -196,6 +234,7 @@ func (m model) renderRow(c collector.Connection, selected bool) string {
 		stateStyled,
 		cols.local, truncate(local, cols.local),
 		cols.remote, truncate(remote, cols.remote),
+		rates,
 		cols.country, flag,
 		truncate(org, cols.org))
 
@@ -212,7 +251,7 @@ func (m model) renderStatusLine() string {
 		return "  " + m.theme.Styles.Warning.Render(m.statusMessage)
 	}
 
-	left := "  " + m.theme.Styles.Normal.Render("t/u proto  l/e/o state  w watch  K kill  s sort  / search  ? help  q quit")
+	left := "  " + m.theme.Styles.Normal.Render("t/u proto  l/e/o state  w watch  K kill  s/> sort  / search  ? help  q quit")
 
 	// show watched count if any
 	if m.watchedCount() > 0 {
@@ -220,6 +259,16 @@ func (m model) renderStatusLine() string {
 		left += m.theme.Styles.Watched.Render(watchedInfo)
 	}
 
+	// collector diagnostics take the place of the key hints when short on room
+	if m.warning != "" {
+		warning := SymbolWarning + " " + m.warning
+		room := m.width - stringWidth(left) - 2
+		if room < 20 {
+			return "  " + m.theme.Styles.Warning.Render(truncate(warning, m.width-4))
+		}
+		left += "  " + m.theme.Styles.Warning.Render(truncate(warning, room))
+	}
+
 	return left
 }
 
@@ -240,7 +289,12 @@ func (m model) renderHelp() string {
   filters
   ───────
   t            toggle tcp
-  u            toggle udp
+  u            toggle udp (incl. udplite)
+  R            toggle raw
+  i            toggle icmp
+  c            toggle sctp
+  p            toggle packet
+  x            toggle unix (hidden by default)
   l            toggle listening
   e            toggle established
   o            toggle other states
@@ -250,6 +304,7 @@ func (m model) renderHelp() string {
   ───────
   s            cycle sort field
   S            reverse sort order
+  >            pick sort field
 
   process management
   ──────────────────
@@ -260,6 +315,7 @@ func (m model) renderHelp() string {
   other
   ─────
   /            search
+  @            pick saved query
   r            refresh now
   q            quit
 
@@ -286,13 +342,44 @@ func (m model) renderDetail() string {
 	}{
 		{"process", c.Process},
 		{"pid", fmt.Sprintf("%d", c.PID)},
-		{"user", c.User},
+		{"ppid", fmt.Sprintf("%d", c.PPID)},
+		{"cmdline", truncate(c.Cmdline, 60)},
+		{"exe", c.Exe},
+		{"cwd", c.Cwd},
+		{"started", formatStartTime(*c)},
+		{"user", formatUser(*c)},
 		{"protocol", c.Proto},
 		{"state", c.State},
-		{"local", fmt.Sprintf("%s:%d", c.Laddr, c.Lport)},
-		{"remote", fmt.Sprintf("%s:%d", c.Raddr, c.Rport)},
+		{"type", c.SockType},
+		{"local", formatEndpoint(c.Proto, c.Laddr, c.Lport)},
+		{"remote", formatEndpoint(c.Proto, c.Raddr, c.Rport)},
+		{"peer", formatPeer(*c)},
 		{"interface", c.Interface},
+		{"namespace", formatNamespace(*c)},
+		{"container", formatContainer(*c)},
+		{"pod", formatPod(*c)},
 		{"inode", fmt.Sprintf("%d", c.Inode)},
+		{"rx", formatBytesRate(c.RxBytes, c.RxRate, m.units)},
+		{"tx", formatBytesRate(c.TxBytes, c.TxRate, m.units)},
+		{"send-q", fmt.Sprintf("%d", c.SendQ)},
+		{"recv-q", fmt.Sprintf("%d", c.RecvQ)},
+		{"retrans", fmt.Sprintf("%d", c.Retransmits)},
+		{"cwnd", fmt.Sprintf("%d", c.Cwnd)},
+		{"timer", c.Timer},
+	}
+
+	// sockets shared across fork list every holder after the main fields
+	if len(c.Owners) > 1 {
+		for i, owner := range c.Owners {
+			label := ""
+			if i == 0 {
+				label = "owners"
+			}
+			fields = append(fields, struct {
+				label string
+				value string
+			}{label, formatOwner(owner)})
+		}
 	}
 
 	for _, f := range fields {
@@ -352,6 +439,58 @@ func (m model) renderKillModal() string {
 	return strings.Join(lines, "\n")
 }
 
+func (m model) renderSortPicker() string {
+	lines := []string{"", m.theme.Styles.Header.Render("  SORT BY  "), ""}
+
+	for i, f := range collector.SortFields {
+		marker := "  "
+		if f == m.sortField {
+			marker = SymbolArrowUp
+			if m.sortReverse {
+				marker = SymbolArrowDown
+			}
+			marker += " "
+		}
+
+		line := fmt.Sprintf("  %s%-8s", marker, sortFieldLabel(f))
+		if i == m.sortPickerCursor {
+			line = m.theme.Styles.Selected.Render(line)
+		}
+		lines = append(lines, line)
+	}
+
+	lines = append(lines, "")
+	lines = append(lines, m.theme.Styles.Normal.Render("  enter select  S reverse  esc close"))
+	lines = append(lines, "")
+
+	return strings.Join(lines, "\n")
+}
+
+func (m model) renderQueryPicker() string {
+	lines := []string{"", m.theme.Styles.Header.Render("  SAVED QUERIES  "), ""}
+
+	width := 0
+	for _, q := range m.queries {
+		if len(q.Name) > width {
+			width = len(q.Name)
+		}
+	}
+
+	for i, q := range m.queries {
+		line := fmt.Sprintf("  @%-*s  %s", width, q.Name, truncate(q.Filter, 40))
+		if i == m.queryPickerCursor {
+			line = m.theme.Styles.Selected.Render(line)
+		}
+		lines = append(lines, line)
+	}
+
+	lines = append(lines, "")
+	lines = append(lines, m.theme.Styles.Normal.Render("  enter select  esc close"))
+	lines = append(lines, "")
+
+	return strings.Join(lines, "\n")
+}
+
 func (m model) overlayModal(background, modal string) string {
 	bgLines := strings.Split(background, "\n")
 	modalLines := strings.Split(modal, "\n")
@@ -508,8 +647,14 @@ type columns struct {
 	remote  int
 	country int
 	org     int
+
+	// rx/s and tx/s, 0 when the terminal is too narrow for them
+	rate int
 }
 
+// rateWidth fits rates up to "1023.9KiB/s"
+const rateWidth = 11
+
 func (m model) columnWidths() columns {
 	available := m.safeWidth() - 16
 
@@ -527,6 +672,11 @@ func (m model) columnWidths() columns {
 	used := c.process + c.port + c.proto + c.state + c.local + c.remote + c.country + c.org
 	extra := available - used
 
+	if extra >= 2*(rateWidth+2) {
+		c.rate = rateWidth
+		extra -= 2 * (rateWidth + 2)
+	}
+
 	if extra > 0 {
 		c.process += extra / 4
 		c.remote += extra / 4
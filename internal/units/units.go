@@ -0,0 +1,47 @@
+package units
+
+import (
+	"math"
+	"strconv"
+	"strings"
+)
+
+// unit systems accepted by the units config key
+const (
+	Auto = "auto"
+	SI   = "si"
+	IEC  = "iec"
+)
+
+var (
+	siSuffixes  = []string{"kB", "MB", "GB", "TB", "PB", "EB"}
+	iecSuffixes = []string{"KiB", "MiB", "GiB", "TiB", "PiB", "EiB"}
+)
+
+// FormatBytes formats n with the units of mode: powers of 1000 for si,
+// powers of 1024 for iec. auto and unknown modes use iec, e.g. 1536 as 1.5KiB.
+func FormatBytes(n int64, mode string) string {
+	base := 1024.0
+	suffixes := iecSuffixes
+	if strings.ToLower(mode) == SI {
+		base = 1000
+		suffixes = siSuffixes
+	}
+
+	value := float64(n)
+	if math.Abs(value) < base {
+		return strconv.FormatInt(n, 10) + "B"
+	}
+
+	i := -1
+	for math.Abs(value) >= base && i < len(suffixes)-1 {
+		value /= base
+		i++
+	}
+	return strconv.FormatFloat(value, 'f', 1, 64) + suffixes[i]
+}
+
+// FormatRate formats a throughput in bytes per second, e.g. 1.5KiB/s
+func FormatRate(bytesPerSec float64, mode string) string {
+	return FormatBytes(int64(math.Round(bytesPerSec)), mode) + "/s"
+}
@@ -0,0 +1,47 @@
+package units
+
+import "testing"
+
+func TestFormatBytes(t *testing.T) {
+	tests := []struct {
+		input    int64
+		mode     string
+		expected string
+	}{
+		{0, Auto, "0B"},
+		{1023, Auto, "1023B"},
+		{1024, Auto, "1.0KiB"},
+		{1536, IEC, "1.5KiB"},
+		{5 * 1024 * 1024, IEC, "5.0MiB"},
+		{999, SI, "999B"},
+		{1000, SI, "1.0kB"},
+		{1500000, SI, "1.5MB"},
+		{1536, "unknown", "1.5KiB"},
+		{-2048, IEC, "-2.0KiB"},
+	}
+
+	for _, tt := range tests {
+		if got := FormatBytes(tt.input, tt.mode); got != tt.expected {
+			t.Errorf("FormatBytes(%d, %q) = %q, expected %q", tt.input, tt.mode, got, tt.expected)
+		}
+	}
+}
+
+func TestFormatRate(t *testing.T) {
+	tests := []struct {
+		input    float64
+		mode     string
+		expected string
+	}{
+		{0, Auto, "0B/s"},
+		{511.6, Auto, "512B/s"},
+		{1536, IEC, "1.5KiB/s"},
+		{2500, SI, "2.5kB/s"},
+	}
+
+	for _, tt := range tests {
+		if got := FormatRate(tt.input, tt.mode); got != tt.expected {
+			t.Errorf("FormatRate(%v, %q) = %q, expected %q", tt.input, tt.mode, got, tt.expected)
+		}
+	}
+}